@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sitanshunandan/glate/configs"
+	"github.com/sitanshunandan/glate/internal/domain"
+	"github.com/sitanshunandan/glate/internal/repository"
+)
+
+// runCatalog dispatches "glate catalog <subcommand>" and returns the exit code.
+func runCatalog(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: glate catalog lint [-file path] [-products path] [-strict]")
+		fmt.Fprintln(os.Stderr, "       glate catalog dump [-file path] [-overrides dir] [-o path]")
+		return 2
+	}
+
+	switch args[0] {
+	case "lint":
+		return runCatalogLint(args[1:])
+	case "dump":
+		return runCatalogDump(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown catalog command %q (available: lint, dump)\n", args[0])
+		return 2
+	}
+}
+
+// runCatalogLint prints every finding. Errors fail the run; -strict fails on warnings too.
+func runCatalogLint(args []string) int {
+	fs := flag.NewFlagSet("catalog lint", flag.ContinueOnError)
+	file := fs.String("file", os.Getenv("GLATE_CATALOG"), "catalog to check; empty checks the embedded default [$GLATE_CATALOG]")
+	products := fs.String("products", "", "products to check against the catalog; with no -file, defaults to the embedded products")
+	strict := fs.Bool("strict", false, "treat warnings as failures")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	raw, err := configs.Read(*file, configs.Substances)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	defs, err := repository.ParseDefinitions(raw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	name := *file
+	if name == "" {
+		name = "(embedded)"
+	}
+
+	// The embedded products only make sense against the embedded catalog
+	issues := repository.Lint(defs)
+	if *products != "" || *file == "" {
+		raw, err := configs.Read(*products, configs.Products)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		list, err := repository.ParseProducts(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		substances := make(map[string]domain.SubstanceDefinition, len(defs))
+		for _, def := range defs {
+			substances[def.ID] = def
+		}
+		issues = append(issues, repository.LintProducts(list, substances)...)
+	}
+	if len(issues) == 0 {
+		fmt.Printf("✅ %s: %d substances, no issues.\n", name, len(defs))
+		return 0
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("\n%s: %d substances, %d issue(s).\n", name, len(defs), len(issues))
+
+	if repository.HasErrors(issues) || *strict {
+		return 1
+	}
+	return 0
+}
+
+// runCatalogDump writes the effective catalog (base + override layers) as JSON,
+// e.g. to start an external catalog from the embedded one.
+func runCatalogDump(args []string) int {
+	fs := flag.NewFlagSet("catalog dump", flag.ContinueOnError)
+	file := fs.String("file", os.Getenv("GLATE_CATALOG"), "base catalog; empty uses the embedded default [$GLATE_CATALOG]")
+	overrides := fs.String("overrides", os.Getenv("GLATE_CATALOG_OVERRIDES"), "directory of *.json override layers to merge; empty means none, as on the server [$GLATE_CATALOG_OVERRIDES]")
+	out := fs.String("o", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	repo, err := openCatalog(*file, *overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	defs := repo.Definitions()
+	raw, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	raw = append(raw, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(raw)
+		return 0
+	}
+	if err := os.WriteFile(*out, raw, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "✅ Wrote %d substances to %s\n", len(defs), *out)
+	return 0
+}
@@ -6,21 +6,33 @@ import (
 	"os"
 	"time"
 
+	"github.com/sitanshunandan/glate/configs"
 	"github.com/sitanshunandan/glate/internal/domain"
 	"github.com/sitanshunandan/glate/internal/engine"
 	"github.com/sitanshunandan/glate/internal/repository"
 )
 
 func main() {
-	fmt.Println("--- Glate: Metabolic Engine Initializing ---")
-
-	// 1. Setup Data Layer
-	configPath := "configs/substances.json"
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("Config file missing: %s", configPath)
+	// Subcommands (e.g., "glate catalog lint"); no arguments runs the demo
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "catalog":
+			os.Exit(runCatalog(os.Args[2:]))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q (available: catalog)\n", os.Args[1])
+			os.Exit(2)
+		}
 	}
 
-	repo, err := repository.NewInMemoryRepo(configPath)
+	runDemo()
+}
+
+// runDemo walks through the advisor scenarios against the shipped catalog.
+func runDemo() {
+	fmt.Println("--- Glate: Metabolic Engine Initializing ---")
+
+	// 1. Setup Data Layer ($GLATE_CATALOG, or the embedded catalog)
+	repo, err := openCatalog(os.Getenv("GLATE_CATALOG"), os.Getenv("GLATE_CATALOG_OVERRIDES"))
 	if err != nil {
 		log.Fatalf("Repo failure: %v", err)
 	}
@@ -45,12 +57,13 @@ func main() {
 	}
 	fmt.Println("inputs: User took 150mg Caffeine 30 mins ago.")
 
-	// User wants to take Iron Bisglycinate NOW
+	// User wants to take 25mg Iron Bisglycinate NOW
 	proposed := "iron-bisglycinate"
-	fmt.Printf("Action: User wants to take %s.\n", proposed)
+	proposedMg := 25.0
+	fmt.Printf("Action: User wants to take %.0fmg %s.\n", proposedMg, proposed)
 
 	// Ask the Advisor
-	conflicts, err := advisor.CheckSafety(activeStack, proposed)
+	conflicts, err := advisor.CheckSafety(activeStack, proposed, proposedMg)
 	if err != nil {
 		log.Fatalf("Analysis failed: %v", err)
 	}
@@ -59,8 +72,13 @@ func main() {
 	if len(conflicts) > 0 {
 		fmt.Println("\n❌ BLOCKED! Conflicts detected:")
 		for _, c := range conflicts {
-			fmt.Printf("   [%s] %s -> %s\n", c.Type, c.SubstanceA, c.SubstanceB)
+			fmt.Printf("   [%s] %s -> %s (%s)\n", c.Type, c.SubstanceA, c.SubstanceB, c.Direction)
 			fmt.Printf("   Reason: %s\n", c.Reason)
+			if c.Trace != nil {
+				for _, line := range c.Trace.Explain() {
+					fmt.Printf("      │ %s\n", line)
+				}
+			}
 			// Round duration for cleaner output
 			wait := c.WaitTime.Round(time.Minute)
 			fmt.Printf("   ⚠️  Please wait %s before taking.\n", wait)
@@ -68,4 +86,34 @@ func main() {
 	} else {
 		fmt.Println("\n✅ SAFE. No interactions detected.")
 	}
+
+	// ---------------------------------------------------------
+	// SCENARIO 2: What helps Iron?
+	// ---------------------------------------------------------
+	fmt.Println("\n--- Scenario: Synergy Suggestions ---")
+
+	suggestions, err := advisor.Suggest(proposed, []string{"vitamin-c"})
+	if err != nil {
+		log.Fatalf("Suggestion lookup failed: %v", err)
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("No synergies known for", proposed)
+	}
+	for _, s := range suggestions {
+		fmt.Printf("   💡 %s\n", s.Message)
+		fmt.Printf("   Why: %s\n", s.Note)
+	}
+}
+
+// openCatalog loads 'path' (empty: the embedded catalog) with the override layers in 'overrideDir'.
+func openCatalog(path, overrideDir string) (*repository.InMemoryRepo, error) {
+	embedded, err := configs.Default(configs.Substances)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewInMemoryRepo(path,
+		repository.WithEmbedded(embedded),
+		repository.WithOverrideDir(overrideDir),
+	)
 }
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/sitanshunandan/glate/configs"
 	"github.com/sitanshunandan/glate/internal/api"
 	"github.com/sitanshunandan/glate/internal/engine"
 	"github.com/sitanshunandan/glate/internal/repository"
@@ -12,28 +18,116 @@ import (
 )
 
 func main() {
+	strictCatalog := flag.Bool("strict-catalog", false, "refuse to start if the catalog fails validation")
+	watchCatalog := flag.Duration("catalog-watch", 5*time.Second, "poll interval for catalog hot-reload (0 disables)")
+	catalogPath := flag.String("catalog", envOr("GLATE_CATALOG", ""), "substance catalog file; empty uses the embedded default [$GLATE_CATALOG]")
+	overrideDir := flag.String("catalog-overrides", envOr("GLATE_CATALOG_OVERRIDES", ""), "directory of *.json override layers merged over the catalog; empty means none [$GLATE_CATALOG_OVERRIDES]")
+	configDir := flag.String("config-dir", envOr("GLATE_CONFIG_DIR", ""), "directory with time_rules.json, load_axes.json and products.json; empty uses the embedded defaults [$GLATE_CONFIG_DIR]")
+	flag.Parse()
+
 	// 1. Dependencies
-	repo, err := repository.NewInMemoryRepo("configs/substances.json")
+	validation := repository.ValidateWarn
+	if *strictCatalog {
+		validation = repository.ValidateStrict
+	}
+	embedded, err := configs.Default(configs.Substances)
 	if err != nil {
 		log.Fatalf("Config Error: %v", err)
 	}
+	repo, err := repository.NewInMemoryRepo(*catalogPath,
+		repository.WithValidation(validation),
+		repository.WithOverrideDir(*overrideDir),
+		repository.WithEmbedded(embedded),
+	)
+	if err != nil {
+		log.Fatalf("Config Error: %v", err)
+	}
+	if *catalogPath == "" {
+		log.Println("📦 Using the embedded catalog; it is read-only (set -catalog to edit it via the admin API)")
+	}
+
+	// Hot-reload: poll the file, and re-read it on SIGHUP
+	if *watchCatalog > 0 {
+		repo.Watch(*watchCatalog, nil)
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			repository.LogReload(repo.Reload())
+		}
+	}()
 
 	calc := engine.NewMetabolicCalculator()
 	advisor := engine.NewAdvisor(repo, calc)
 	sessionStore := store.NewSessionStore()
+	profileStore := store.NewProfileStore()
+	auditLog := store.NewAuditLog()
+
+	// Time-of-day rules are optional: run without them if the file is missing
+	if rules, err := loadConfig(*configDir, configs.TimeRules, repository.ParseTimeRules); err != nil {
+		log.Printf("⚠️  Time rules not loaded: %v", err)
+	} else if err := advisor.SetTimeRules(rules); err != nil {
+		log.Fatalf("Config Error: %v", err)
+	}
 
-	handler := api.NewHandler(advisor, sessionStore, repo, calc)
+	// Load axes are optional too
+	if axes, err := loadConfig(*configDir, configs.LoadAxes, repository.ParseAxes); err != nil {
+		log.Printf("⚠️  Load axes not loaded: %v", err)
+	} else if err := advisor.SetLoadAxes(axes); err != nil {
+		log.Fatalf("Config Error: %v", err)
+	}
+
+	handler := api.NewHandler(advisor, sessionStore, profileStore, auditLog, repo, calc)
+
+	// Products ("a cup of coffee") are optional as well
+	if products, err := loadConfig(*configDir, configs.Products, repository.ParseProducts); err != nil {
+		log.Printf("⚠️  Products not loaded: %v", err)
+	} else {
+		substances, _ := repo.GetAll()
+		issues := repository.LintProducts(products, substances)
+		for _, issue := range issues {
+			log.Printf("⚠️  Products %s", issue)
+		}
+		if *strictCatalog && repository.HasErrors(issues) {
+			log.Fatalf("Config Error: products failed validation with %d error(s)", repository.ErrorCount(issues))
+		}
+		handler.Products = repository.NewProductCatalog(products)
+	}
 
 	// 2. Start the Background Monitor (NEW)
 	// We set it to run every 10 seconds for the demo.
 	monitor := engine.NewMonitor(sessionStore, repo, calc)
+	monitor.Axes = advisor.LoadAxes()
 	monitor.Start(10 * time.Second)
 
 	// 3. Router
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /analyze", handler.AnalyzeEndpoint)
 	mux.HandleFunc("POST /ingest", handler.IngestEndpoint)
+	mux.HandleFunc("GET /products", handler.ProductsEndpoint)
 	mux.HandleFunc("GET /status", handler.StatusEndpoint)
+	mux.HandleFunc("GET /suggest", handler.SuggestEndpoint)
+	mux.HandleFunc("GET /profile", handler.GetProfileEndpoint)
+	mux.HandleFunc("PUT /profile", handler.PutProfileEndpoint)
+	mux.HandleFunc("GET /cutoff", handler.CutoffEndpoint)
+	mux.HandleFunc("POST /matrix", handler.MatrixEndpoint)
+	mux.HandleFunc("POST /washout", handler.WashoutEndpoint)
+	mux.HandleFunc("GET /audit", handler.AuditEndpoint)
+	mux.HandleFunc("GET /substances", handler.ListSubstancesEndpoint)
+	mux.HandleFunc("GET /substances/search", handler.SearchSubstancesEndpoint)
+	mux.HandleFunc("GET /substances/{id}", handler.GetSubstanceEndpoint)
+	mux.HandleFunc("GET /substances/{id}/provenance", handler.ProvenanceEndpoint)
+	mux.HandleFunc("GET /profile/substances", handler.ListUserSubstancesEndpoint)
+	mux.HandleFunc("PUT /profile/substances", handler.PutUserSubstanceEndpoint)
+	mux.HandleFunc("DELETE /profile/substances/{id}", handler.DeleteUserSubstanceEndpoint)
+	mux.HandleFunc("GET /catalog/versions", handler.CatalogVersionsEndpoint)
+
+	// Admin endpoints need GLATE_ADMIN_TOKEN
+	mux.HandleFunc("POST /admin/reload", api.RequireAdmin(handler.ReloadEndpoint))
+	mux.HandleFunc("POST /substances", api.RequireAdmin(handler.CreateSubstanceEndpoint))
+	mux.HandleFunc("PUT /substances/{id}", api.RequireAdmin(handler.UpdateSubstanceEndpoint))
+	mux.HandleFunc("DELETE /substances/{id}", api.RequireAdmin(handler.DeleteSubstanceEndpoint))
 
 	// 4. Server
 	srv := &http.Server{
@@ -48,3 +142,25 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// envOr reads a flag default from the environment.
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+// loadConfig parses 'name' from 'dir', or the embedded default when 'dir' is empty.
+func loadConfig[T any](dir, name string, parse func([]byte) (T, error)) (T, error) {
+	path := ""
+	if dir != "" {
+		path = filepath.Join(dir, name)
+	}
+	raw, err := configs.Read(path, name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return parse(raw)
+}
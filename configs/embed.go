@@ -0,0 +1,32 @@
+// Package configs ships the default catalog inside the binaries,
+// so they work from any directory.
+package configs
+
+import (
+	"embed"
+	"os"
+)
+
+// Default file names, as embedded.
+const (
+	Substances = "substances.json"
+	Products   = "products.json"
+	TimeRules  = "time_rules.json"
+	LoadAxes   = "load_axes.json"
+)
+
+//go:embed substances.json products.json time_rules.json load_axes.json
+var files embed.FS
+
+// Default returns an embedded file (e.g., configs.Substances).
+func Default(name string) ([]byte, error) {
+	return files.ReadFile(name)
+}
+
+// Read returns the file at 'path', or the embedded default 'name' when 'path' is empty.
+func Read(path, name string) ([]byte, error) {
+	if path == "" {
+		return Default(name)
+	}
+	return os.ReadFile(path)
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+	"github.com/sitanshunandan/glate/internal/repository"
+)
+
+// AdminTokenEnv names the environment variable holding the admin bearer token.
+// If it is unset, every admin endpoint is refused.
+const AdminTokenEnv = "GLATE_ADMIN_TOKEN"
+
+// RequireAdmin guards an endpoint behind "Authorization: Bearer <token>".
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv(AdminTokenEnv)
+		if token == "" {
+			http.Error(w, "admin API disabled: "+AdminTokenEnv+" not set", http.StatusForbidden)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// CatalogChangeResponse is returned by every successful catalog write.
+type CatalogChangeResponse struct {
+	Version    repository.CatalogVersion   `json:"version"`
+	Definition *domain.SubstanceDefinition `json:"definition,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 11: Catalog Read (GET /substances, GET /substances/{id})
+// -------------------------------------------------------------------------
+
+func (h *Handler) ListSubstancesEndpoint(w http.ResponseWriter, r *http.Request) {
+	all, err := h.Repo.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defs := make([]domain.SubstanceDefinition, 0, len(all))
+	for _, id := range slices.Sorted(maps.Keys(all)) {
+		defs = append(defs, all[id])
+	}
+	writeJSON(w, http.StatusOK, defs)
+}
+
+func (h *Handler) GetSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	_, repo := h.forUser(r.URL.Query().Get("user_id"))
+	def, err := repo.GetDefinition(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, def)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 12: Catalog Admin (POST/PUT/DELETE /substances)
+// -------------------------------------------------------------------------
+
+func (h *Handler) CreateSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.writable(w)
+	if !ok {
+		return
+	}
+	var def domain.SubstanceDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if def.ID == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	version, err := repo.Create(def)
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, CatalogChangeResponse{Version: version, Definition: &def})
+}
+
+func (h *Handler) UpdateSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.writable(w)
+	if !ok {
+		return
+	}
+	var def domain.SubstanceDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The path wins; a mismatching body ID is almost certainly a mistake
+	id := r.PathValue("id")
+	if def.ID == "" {
+		def.ID = id
+	} else if def.ID != id {
+		http.Error(w, "body id does not match path", http.StatusBadRequest)
+		return
+	}
+
+	version, err := repo.Update(def)
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, CatalogChangeResponse{Version: version, Definition: &def})
+}
+
+func (h *Handler) DeleteSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	repo, ok := h.writable(w)
+	if !ok {
+		return
+	}
+	version, err := repo.Delete(r.PathValue("id"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, CatalogChangeResponse{Version: version})
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 13: Catalog History (GET /catalog/versions)
+// -------------------------------------------------------------------------
+
+func (h *Handler) CatalogVersionsEndpoint(w http.ResponseWriter, r *http.Request) {
+	versioned, ok := h.Repo.(repository.Versioned)
+	if !ok {
+		http.Error(w, "repository is not versioned", http.StatusNotImplemented)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"current":  versioned.Version(),
+		"versions": versioned.History(),
+	})
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 14: Substance Search (GET /substances/search?q=...&limit=...&user_id=...)
+// -------------------------------------------------------------------------
+
+func (h *Handler) SearchSubstancesEndpoint(w http.ResponseWriter, r *http.Request) {
+	_, repo := h.forUser(r.URL.Query().Get("user_id"))
+	searcher, ok := repo.(repository.Searcher)
+	if !ok {
+		http.Error(w, "repository does not support search", http.StatusNotImplemented)
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "q required", http.StatusBadRequest)
+		return
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	hits := searcher.Search(q, limit)
+	if hits == nil {
+		hits = []repository.SearchHit{}
+	}
+	writeJSON(w, http.StatusOK, hits)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 15: Field Provenance (GET /substances/{id}/provenance?user_id=...)
+// -------------------------------------------------------------------------
+
+func (h *Handler) ProvenanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	layered, ok := h.Repo.(repository.Layered)
+	if !ok {
+		http.Error(w, "repository is not layered", http.StatusNotImplemented)
+		return
+	}
+	prov, err := layered.Provenance(r.URL.Query().Get("user_id"), r.PathValue("id"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prov)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 16: Private Definitions (GET/PUT /profile/substances, DELETE /profile/substances/{id})
+// -------------------------------------------------------------------------
+
+func (h *Handler) ListUserSubstancesEndpoint(w http.ResponseWriter, r *http.Request) {
+	layered, userID, ok := h.userLayer(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, layered.UserDefinitions(userID))
+}
+
+// PutUserSubstanceEndpoint takes a partial substance object with an "id":
+// only the fields present override the shared catalog for this user.
+func (h *Handler) PutUserSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	layered, userID, ok := h.userLayer(w, r)
+	if !ok {
+		return
+	}
+	var entry json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	def, err := layered.SetUserDefinition(userID, entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, http.StatusOK, def)
+}
+
+func (h *Handler) DeleteUserSubstanceEndpoint(w http.ResponseWriter, r *http.Request) {
+	layered, userID, ok := h.userLayer(w, r)
+	if !ok {
+		return
+	}
+	if err := layered.DeleteUserDefinition(userID, r.PathValue("id")); err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) userLayer(w http.ResponseWriter, r *http.Request) (repository.Layered, string, bool) {
+	layered, ok := h.Repo.(repository.Layered)
+	if !ok {
+		http.Error(w, "repository is not layered", http.StatusNotImplemented)
+		return nil, "", false
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id required", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return layered, userID, true
+}
+
+func (h *Handler) writable(w http.ResponseWriter) (repository.WritableRepository, bool) {
+	repo, ok := h.Repo.(repository.WritableRepository)
+	if !ok {
+		http.Error(w, "repository is read-only", http.StatusNotImplemented)
+	}
+	return repo, ok
+}
+
+// writeCatalogError maps repository write failures to status codes.
+// Anything that isn't a lookup problem is a validation rejection.
+func writeCatalogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrConflict), errors.Is(err, repository.ErrReadOnly):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	}
+}
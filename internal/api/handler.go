@@ -2,7 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,23 +22,83 @@ import (
 // -------------------------------------------------------------------------
 
 // AnalysisRequest is for the stateless "Check Safety" endpoint.
+// Either send a single ProposedID/ProposedMg, or a whole batch in Proposed.
 type AnalysisRequest struct {
-	ActiveStack []ActiveDoseDTO `json:"active_stack"`
-	ProposedID  string          `json:"proposed_id"`
+	UserID      string            `json:"user_id,omitempty"` // Optional; daily totals come from this user's history
+	ActiveStack []ActiveDoseDTO   `json:"active_stack"`
+	ProposedID  string            `json:"proposed_id,omitempty"`
+	ProposedMg  float64           `json:"proposed_mg,omitempty"`     // Optional; enables dose-dependent rules
+	ProposedAmt *domain.Amount    `json:"proposed_amount,omitempty"` // Instead of proposed_mg (e.g., "500 mcg")
+	ProposedAt  string            `json:"proposed_at,omitempty"`     // RFC3339; defaults to now. Batch offsets count from here
+	Proposed    []ProposedDoseDTO `json:"proposed,omitempty"`        // A batch (e.g., the morning stack)
+	Regimen     []string          `json:"regimen,omitempty"`         // Planned substances, ranked first in suggestions
+
+	// Weakest evidence to report ("established" = established only, "theoretical" includes theoretical).
+	// Defaults to the profile's setting, then to everything.
+	Strictness domain.EvidenceLevel `json:"strictness,omitempty"`
+}
+
+// ProposedDoseDTO is one item of a batch analysis.
+type ProposedDoseDTO struct {
+	SubstanceID   string         `json:"substance_id"`
+	AmountMg      float64        `json:"amount_mg,omitempty"`
+	Amount        *domain.Amount `json:"amount,omitempty"`         // Instead of amount_mg
+	OffsetMinutes float64        `json:"offset_minutes,omitempty"` // Relative to proposed_at
 }
 
 // ActiveDoseDTO helps us parse JSON time strings safely.
 type ActiveDoseDTO struct {
-	SubstanceID   string  `json:"substance_id"`
-	AmountMg      float64 `json:"amount_mg"`
-	IngestedAtStr string  `json:"ingested_at"`
+	SubstanceID   string         `json:"substance_id"`
+	AmountMg      float64        `json:"amount_mg"`
+	Amount        *domain.Amount `json:"amount,omitempty"` // Instead of amount_mg (e.g., "1000 IU")
+	IngestedAtStr string         `json:"ingested_at"`
 }
 
 // IngestRequest is for the stateful "Take Pill" endpoint.
+// Send either a substance with an amount, or a product with a number of servings.
 type IngestRequest struct {
-	UserID      string  `json:"user_id"`
-	SubstanceID string  `json:"substance_id"`
-	AmountMg    float64 `json:"amount_mg"`
+	UserID      string         `json:"user_id"`
+	SubstanceID string         `json:"substance_id,omitempty"`
+	AmountMg    float64        `json:"amount_mg,omitempty"`
+	Amount      *domain.Amount `json:"amount,omitempty"` // Instead of amount_mg (e.g., "1000 IU", {"value": 2, "unit": "g"})
+	ProductID   string         `json:"product_id,omitempty"`
+	Servings    float64        `json:"servings,omitempty"` // Defaults to 1
+	Override    *OverrideDTO   `json:"override,omitempty"` // Required to ingest despite DANGEROUS conflicts
+}
+
+// OverrideDTO is an explicit, audited "I know, do it anyway".
+type OverrideDTO struct {
+	Reason string `json:"reason"`
+}
+
+// IngestResponse confirms a tracked dose. For a product, the top-level dose
+// fields are empty and each component is reported in Components.
+type IngestResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	DoseResult
+
+	ProductIngestID string       `json:"product_ingest_id,omitempty"` // Shared by every dose of a product intake
+	Components      []DoseResult `json:"components,omitempty"`
+
+	Candidates []repository.SearchHit `json:"candidates,omitempty"` // Possible matches when the name didn't resolve
+}
+
+// DoseResult is what ingest found out about one dose.
+type DoseResult struct {
+	SubstanceID string                 `json:"substance_id,omitempty"`
+	AmountMg    float64                `json:"amount_mg,omitempty"`   // Active amount
+	LabelledID  string                 `json:"labelled_id,omitempty"` // Set when a salt form was taken
+	LabelledMg  float64                `json:"labelled_mg,omitempty"`
+	Display     *domain.Amount         `json:"display,omitempty"` // AmountMg in the user's preferred unit
+	DoseID      string                 `json:"dose_id,omitempty"`
+	Limit       engine.LimitStatus     `json:"limit,omitzero"`      // Remaining daily allowance after this dose
+	Timing      []engine.TimeViolation `json:"timing,omitempty"`    // Time-of-day rules this dose broke
+	Load        []engine.LoadWarning   `json:"load,omitempty"`      // Cumulative load axes over threshold
+	Peak        *engine.PeakProjection `json:"peak,omitempty"`      // Set when the projected peak exceeds the toxic level
+	Conflicts   []engine.Conflict      `json:"conflicts,omitempty"` // Severe conflicts (blocking unless overridden)
+	Warnings    []engine.Conflict      `json:"warnings,omitempty"`  // Lesser conflicts, reported but not blocking
+	AuditID     string                 `json:"audit_id,omitempty"`  // Set when an override was recorded
 }
 
 // -------------------------------------------------------------------------
@@ -42,27 +107,77 @@ type IngestRequest struct {
 
 // Handler holds the dependencies.
 type Handler struct {
-	Advisor *engine.Advisor
-	Store   *store.SessionStore
-	Repo    repository.Repository       // <--- NEW
-	Calc    *engine.MetabolicCalculator // <--- NEW
+	Advisor  *engine.Advisor
+	Store    *store.SessionStore
+	Profiles *store.ProfileStore
+	Audit    *store.AuditLog
+	Repo     repository.Repository       // <--- NEW
+	Calc     *engine.MetabolicCalculator // <--- NEW
+	Products *repository.ProductCatalog  // Optional; nil means substances only
 }
 
 // NewHandler injects dependencies.
-func NewHandler(advisor *engine.Advisor, store *store.SessionStore, repo repository.Repository, calc *engine.MetabolicCalculator) *Handler {
+func NewHandler(advisor *engine.Advisor, store *store.SessionStore, profiles *store.ProfileStore, audit *store.AuditLog, repo repository.Repository, calc *engine.MetabolicCalculator) *Handler {
 	return &Handler{
-		Advisor: advisor,
-		Store:   store,
-		Repo:    repo,
-		Calc:    calc,
+		Advisor:  advisor,
+		Store:    store,
+		Profiles: profiles,
+		Audit:    audit,
+		Repo:     repo,
+		Calc:     calc,
 	}
 }
 
+// ItemAnalysis is the verdict for one proposed dose.
+type ItemAnalysis struct {
+	Index       int                    `json:"index"`
+	SubstanceID string                 `json:"substance_id"` // The active moiety, for salt forms
+	AmountMg    float64                `json:"amount_mg"`
+	LabelledID  string                 `json:"labelled_id,omitempty"` // Set when a salt form was proposed
+	LabelledMg  float64                `json:"labelled_mg,omitempty"`
+	Display     *domain.Amount         `json:"display,omitempty"` // AmountMg in the user's preferred unit
+	At          time.Time              `json:"at"`
+	Conflicts   []engine.Conflict      `json:"conflicts,omitempty"`  // Against the active stack
+	Suppressed  []engine.Conflict      `json:"suppressed,omitempty"` // Hidden by the strictness setting
+	Limit       engine.LimitStatus     `json:"limit"`
+	Timing      []engine.TimeViolation `json:"timing,omitempty"`
+	Load        []engine.LoadWarning   `json:"load,omitempty"` // Cumulative load axes over threshold
+	Peak        engine.PeakProjection  `json:"peak"`           // Projected Cmax with this dose on top of the stack
+	Suggestions []engine.Suggestion    `json:"suggestions,omitempty"`
+}
+
+// AnalysisResponse is the result of POST /analyze.
+type AnalysisResponse struct {
+	Safe      bool                  `json:"safe"`
+	Conflicts []engine.Conflict     `json:"conflicts,omitempty"` // Every item's conflicts with the active stack
+	Pairwise  []engine.PairConflict `json:"pairwise,omitempty"`  // Conflicts among the proposed items themselves
+	Items     []ItemAnalysis        `json:"items"`
+
+	// Single proposed_id requests also get their item's verdict at the top level,
+	// where it lived before batches existed
+	Limit       *engine.LimitStatus    `json:"limit,omitempty"`
+	Timing      []engine.TimeViolation `json:"timing,omitempty"`
+	Suggestions []engine.Suggestion    `json:"suggestions,omitempty"`
+
+	Strictness         domain.EvidenceLevel  `json:"strictness,omitempty"`          // The evidence filter that was applied
+	Suppressed         []engine.Conflict     `json:"suppressed,omitempty"`          // Stack conflicts hidden by the filter
+	SuppressedPairwise []engine.PairConflict `json:"suppressed_pairwise,omitempty"` // Batch conflicts hidden by the filter
+
+	CatalogVersion int `json:"catalog_version,omitempty"` // Catalog the analysis was computed against
+}
+
 type StatusResponse struct {
-	Substance   string  `json:"substance"`
-	OriginalMg  float64 `json:"original_mg"`
-	CurrentMg   float64 `json:"current_mg"` // The calculated value
-	TimeElapsed string  `json:"time_elapsed"`
+	Substance   string         `json:"substance"`
+	OriginalMg  float64        `json:"original_mg"`
+	CurrentMg   float64        `json:"current_mg"`        // The calculated value
+	Display     *domain.Amount `json:"display,omitempty"` // CurrentMg in the user's preferred unit
+	TimeElapsed string         `json:"time_elapsed"`
+
+	// Therapeutic window, judged on all active doses of this substance combined
+	TotalMg         float64            `json:"total_mg"`
+	Window          engine.WindowLabel `json:"window,omitempty"`
+	NextWindow      engine.WindowLabel `json:"next_window,omitempty"`
+	WindowChangesIn string             `json:"window_changes_in,omitempty"`
 }
 
 // -------------------------------------------------------------------------
@@ -92,26 +207,176 @@ func (h *Handler) AnalyzeEndpoint(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	// 3. Call the Engine
-	conflicts, err := h.Advisor.CheckSafety(domainStack, req.ProposedID)
+	// 3. Build the batch (a single proposed_id is a batch of one)
+	now := time.Now()
+	base := now
+	if req.ProposedAt != "" {
+		var err error
+		if base, err = time.Parse(time.RFC3339, req.ProposedAt); err != nil {
+			http.Error(w, "Invalid time format (use RFC3339): "+req.ProposedAt, http.StatusBadRequest)
+			return
+		}
+	}
+	items := req.Proposed
+	if len(items) == 0 {
+		if req.ProposedID == "" {
+			http.Error(w, "proposed_id or proposed required", http.StatusBadRequest)
+			return
+		}
+		items = []ProposedDoseDTO{{SubstanceID: req.ProposedID, AmountMg: req.ProposedMg, Amount: req.ProposedAmt}}
+	}
+	batch := make([]engine.ProposedDose, len(items))
+	for i, item := range items {
+		batch[i] = engine.ProposedDose{
+			SubstanceID: item.SubstanceID,
+			AmountMg:    item.AmountMg,
+			At:          base.Add(time.Duration(item.OffsetMinutes * float64(time.Minute))),
+		}
+	}
+
+	// 4. Amounts with units become mg, and salt forms count as their active moiety
+	// from here on. The user's private definitions apply on top of the shared catalog.
+	advisor, _ := h.forUser(req.UserID)
+	for i := range domainStack {
+		var err error
+		if domainStack[i].AmountMg, err = amountMg(advisor, domainStack[i].SubstanceID, domainStack[i].AmountMg, req.ActiveStack[i].Amount); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if domainStack[i], err = advisor.ToActive(domainStack[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for i := range batch {
+		var err error
+		if items[i].AmountMg, err = amountMg(advisor, items[i].SubstanceID, items[i].AmountMg, items[i].Amount); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		batch[i].AmountMg = items[i].AmountMg
+		if batch[i].SubstanceID, batch[i].AmountMg, err = advisor.ActiveAmount(batch[i].SubstanceID, batch[i].AmountMg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 5. Call the Engine: every item vs the stack, and every pair within the batch
+	stackConflicts, pairs, err := advisor.CheckBatch(domainStack, batch)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// 4. Format Response
-	type Response struct {
-		Safe      bool              `json:"safe"`
-		Conflicts []engine.Conflict `json:"conflicts,omitempty"`
+	// 6. Per-item checks. Daily caps use the stored history if we know the user,
+	// else the posted stack, plus the batch items taken before this one.
+	history := domainStack
+	if req.UserID != "" {
+		history = h.Store.GetDosesSince(req.UserID, base.Add(-engine.LimitWindow))
 	}
+	profile := h.Profiles.GetProfile(req.UserID)
+	loadStack := append([]domain.ActiveDose(nil), domainStack...)
 
-	resp := Response{
-		Safe:      len(conflicts) == 0,
-		Conflicts: conflicts,
+	// Evidence filter: the request wins, then the profile's default
+	strictness := req.Strictness
+	if strictness == "" {
+		strictness = profile.Strictness
+	}
+	if err := engine.ValidateStrictness(strictness); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := AnalysisResponse{Strictness: strictness}
+	for _, pair := range pairs {
+		if engine.MeetsStrictness(pair.Conflict, strictness) {
+			resp.Pairwise = append(resp.Pairwise, pair)
+		} else {
+			resp.SuppressedPairwise = append(resp.SuppressedPairwise, pair)
+		}
+	}
+	resp.Safe = len(resp.Pairwise) == 0
+	if versioned, ok := h.Repo.(repository.Versioned); ok {
+		resp.CatalogVersion = versioned.Version()
 	}
+	for i, item := range batch {
+		limit, err := advisor.CheckLimits(history, item.SubstanceID, item.AmountMg, item.At)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		history = append(history, domain.ActiveDose{SubstanceID: item.SubstanceID, AmountMg: item.AmountMg, IngestedAt: item.At})
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+		// Health conditions and long-term medications count as conflicts too
+		contra, err := advisor.CheckContraindications(item.SubstanceID, profile)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		conflicts, suppressed := engine.FilterByEvidence(append(stackConflicts[i], contra...), strictness)
+
+		// Time-of-day rules, on the user's own clock
+		timing, err := advisor.CheckTiming(item.SubstanceID, item.At, profile)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Cumulative load, including the batch items taken before this one
+		load, err := advisor.CheckLoad(loadStack, item.SubstanceID, item.AmountMg, item.At)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Projected peak, with the same stack
+		peak, err := advisor.ProjectPeak(loadStack, item)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		loadStack = append(loadStack, domain.ActiveDose{SubstanceID: item.SubstanceID, AmountMg: item.AmountMg, IngestedAt: item.At})
+
+		// Look for helpers worth taking alongside
+		suggestions, err := advisor.Suggest(item.SubstanceID, req.Regimen)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		analysis := ItemAnalysis{
+			Index:       i,
+			SubstanceID: item.SubstanceID,
+			AmountMg:    item.AmountMg,
+			At:          item.At,
+			Conflicts:   conflicts,
+			Suppressed:  suppressed,
+			Limit:       limit,
+			Timing:      timing,
+			Load:        load,
+			Peak:        peak,
+			Suggestions: suggestions,
+		}
+		if items[i].SubstanceID != item.SubstanceID {
+			analysis.LabelledID, analysis.LabelledMg = items[i].SubstanceID, items[i].AmountMg
+		}
+		analysis.Display = advisor.DisplayDose(domain.ActiveDose{
+			SubstanceID: item.SubstanceID, AmountMg: item.AmountMg,
+			LabelledID: analysis.LabelledID, LabelledMg: analysis.LabelledMg,
+		}, profile)
+		resp.Items = append(resp.Items, analysis)
+		resp.Conflicts = append(resp.Conflicts, conflicts...)
+		resp.Suppressed = append(resp.Suppressed, suppressed...)
+		if len(conflicts) > 0 || limit.Exceeded() || len(timing) > 0 || len(load) > 0 || peak.Exceeds {
+			resp.Safe = false
+		}
+	}
+
+	// 7. Format Response
+	if len(req.Proposed) == 0 {
+		single := resp.Items[0]
+		resp.Limit, resp.Timing, resp.Suggestions = &single.Limit, single.Timing, single.Suggestions
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // -------------------------------------------------------------------------
@@ -125,19 +390,284 @@ func (h *Handler) IngestEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create the Domain Object
-	dose := domain.ActiveDose{
+	now := time.Now()
+	advisor, repo := h.forUser(req.UserID)
+
+	// 1. Work out the doses: one substance, or every component of a product
+	var doses []domain.ActiveDose
+	if req.ProductID != "" {
+		product, err := h.Products.GetProduct(req.ProductID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// The linter only warns about these unless the catalog is strict
+		if len(product.Components) == 0 {
+			http.Error(w, "product "+product.ID+" has no components", http.StatusUnprocessableEntity)
+			return
+		}
+		servings := req.Servings
+		if servings == 0 {
+			servings = 1
+		}
+		if servings < 0 {
+			http.Error(w, "servings must be positive", http.StatusBadRequest)
+			return
+		}
+		ingestID := uuid.New().String()
+		for _, c := range product.Components {
+			doses = append(doses, domain.ActiveDose{
+				SubstanceID:     c.SubstanceID,
+				AmountMg:        c.AmountMg * servings,
+				ProductID:       product.ID,
+				ProductIngestID: ingestID,
+			})
+		}
+	} else {
+		// Accept names and aliases ("coffee", "vit c"), but never guess between several.
+		// Exact IDs skip the lookup; the user's private names and aliases count too.
+		searcher, canSearch := repo.(repository.Searcher)
+		if _, err := repo.GetDefinition(req.SubstanceID); err != nil && canSearch {
+			id, candidates, err := searcher.Resolve(req.SubstanceID)
+			if err != nil {
+				writeJSON(w, http.StatusUnprocessableEntity, IngestResponse{
+					Status:     "unresolved",
+					Message:    err.Error(),
+					Candidates: candidates,
+				})
+				return
+			}
+			req.SubstanceID = id
+		}
+		var err error
+		if req.AmountMg, err = amountMg(advisor, req.SubstanceID, req.AmountMg, req.Amount); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		doses = append(doses, domain.ActiveDose{SubstanceID: req.SubstanceID, AmountMg: req.AmountMg})
+	}
+
+	// Salt forms are tracked as their active moiety; the label is kept alongside
+	for i := range doses {
+		var err error
+		if doses[i], err = advisor.ToActive(doses[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 2. Check every dose against the stack as it was before this intake.
+	// Components of one product are a single formulation, so they aren't checked
+	// against each other, but they still add up towards caps, load and peaks.
+	profile := h.Profiles.GetProfile(req.UserID)
+	history := h.Store.GetDosesSince(req.UserID, now.Add(-engine.LimitWindow))
+	stack := knownDoses(repo, h.Store.GetStack(req.UserID))
+	results := make([]DoseResult, len(doses))
+	var refused, blocked bool
+	for i := range doses {
+		doses[i].ID = uuid.New().String()
+		doses[i].IngestedAt = now
+
+		result, err := h.checkDose(advisor, profile, history, stack, doses[:i], doses[i])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		results[i] = result
+		refused = refused || result.Limit.Exceeded()
+		blocked = blocked || len(result.Conflicts) > 0
+		history = append(history, doses[i])
+	}
+	respond := func(status int, resp IngestResponse) {
+		if req.ProductID != "" {
+			resp.ProductIngestID = doses[0].ProductIngestID
+			resp.Components = results
+		} else {
+			resp.DoseResult = results[0]
+		}
+		writeJSON(w, status, resp)
+	}
+
+	// 3. Refuse doses that would break the daily or single-dose cap
+	if refused {
+		message := "Dose would exceed an intake limit"
+		for _, res := range results {
+			if res.Limit.Exceeded() {
+				message = res.Limit.Message
+				break
+			}
+		}
+		clearDoseIDs(results)
+		respond(http.StatusUnprocessableEntity, IngestResponse{Status: "refused", Message: message})
+		return
+	}
+
+	// 4. DANGEROUS blocks unless overridden; everything else was advisory
+	overridden := req.Override != nil && strings.TrimSpace(req.Override.Reason) != ""
+	if blocked && !overridden {
+		clearDoseIDs(results)
+		respond(http.StatusConflict, IngestResponse{
+			Status:  "blocked",
+			Message: "Dangerous interaction with the active stack or health profile; resend with an override reason to ingest anyway",
+		})
+		return
+	}
+
+	// 5. Save to Store; overrides leave a trail
+	for i, dose := range doses {
+		h.Store.AddDose(req.UserID, dose)
+		if len(results[i].Conflicts) > 0 {
+			results[i].AuditID = h.recordOverride(req.UserID, dose, req.Override.Reason, results[i].Conflicts)
+		}
+
+		// Report the allowance left after this dose
+		if remaining := results[i].Limit.RemainingMg; remaining != nil {
+			*remaining -= dose.AmountMg
+		}
+	}
+
+	respond(http.StatusCreated, IngestResponse{
+		Status:  "ingested",
+		Message: "Dose tracked successfully",
+	})
+}
+
+// checkDose runs the ingest checks for one dose taken on top of 'stack'.
+// 'history' is the rolling window used for intake caps. 'companions' are doses
+// taken in the same intake: they count towards load and peaks, not interactions.
+func (h *Handler) checkDose(advisor *engine.Advisor, profile domain.UserProfile, history, stack, companions []domain.ActiveDose, dose domain.ActiveDose) (DoseResult, error) {
+	result := DoseResult{
+		SubstanceID: dose.SubstanceID,
+		AmountMg:    dose.AmountMg,
+		LabelledID:  dose.LabelledID,
+		LabelledMg:  dose.LabelledMg,
+		Display:     advisor.DisplayDose(dose, profile),
+		DoseID:      dose.ID,
+	}
+
+	// Daily and single-dose caps
+	limit, err := advisor.CheckLimits(history, dose.SubstanceID, dose.AmountMg, dose.IngestedAt)
+	if err != nil {
+		return result, err
+	}
+	result.Limit = limit
+	if limit.Exceeded() {
+		return result, nil
+	}
+
+	// Interactions with the active stack and the user's health profile:
+	// DANGEROUS blocks, the rest warns
+	conflicts, err := advisor.CheckSafetyAt(stack, dose.SubstanceID, dose.AmountMg, dose.IngestedAt)
+	if err != nil {
+		return result, err
+	}
+	contra, err := advisor.CheckContraindications(dose.SubstanceID, profile)
+	if err != nil {
+		return result, err
+	}
+	result.Conflicts, result.Warnings = engine.SplitSevere(append(conflicts, contra...))
+
+	// Time-of-day rules are advisory on ingest
+	if result.Timing, err = advisor.CheckTiming(dose.SubstanceID, dose.IngestedAt, profile); err != nil {
+		return result, err
+	}
+
+	// Cumulative load is advisory too
+	combined := append(slices.Clone(stack), companions...)
+	if result.Load, err = advisor.CheckLoad(combined, dose.SubstanceID, dose.AmountMg, dose.IngestedAt); err != nil {
+		return result, err
+	}
+
+	// So is the projected peak
+	peak, err := advisor.ProjectPeak(combined, engine.ProposedDose{SubstanceID: dose.SubstanceID, AmountMg: dose.AmountMg, At: dose.IngestedAt})
+	if err != nil {
+		return result, err
+	}
+	if peak.Exceeds {
+		result.Peak = &peak
+	}
+
+	return result, nil
+}
+
+// amountMg picks the dose from amount_mg or a unit-aware amount. Sending both
+// is ambiguous, so it is rejected rather than letting one silently win.
+func amountMg(advisor *engine.Advisor, substanceID string, mg float64, amount *domain.Amount) (float64, error) {
+	if amount == nil {
+		return mg, nil
+	}
+	if mg != 0 {
+		return 0, fmt.Errorf("%s: send amount or amount_mg, not both", substanceID)
+	}
+	return advisor.ToMg(substanceID, *amount)
+}
+
+// knownDoses drops stored doses whose substance is no longer in 'repo'
+// (a deleted private definition, an admin removal or a reload). Like /status,
+// skip them rather than failing every later request for that user.
+func knownDoses(repo repository.Repository, stack []domain.ActiveDose) []domain.ActiveDose {
+	known := make([]domain.ActiveDose, 0, len(stack))
+	for _, dose := range stack {
+		if _, err := repo.GetDefinition(dose.SubstanceID); err == nil {
+			known = append(known, dose)
+		}
+	}
+	return known
+}
+
+// clearDoseIDs drops the IDs of doses that were never stored.
+func clearDoseIDs(results []DoseResult) {
+	for i := range results {
+		results[i].DoseID = ""
+	}
+}
+
+// recordOverride writes an audit entry for a dose ingested despite severe conflicts.
+func (h *Handler) recordOverride(userID string, dose domain.ActiveDose, reason string, severe []engine.Conflict) string {
+	entry := domain.AuditEntry{
 		ID:          uuid.New().String(),
-		SubstanceID: req.SubstanceID,
-		AmountMg:    req.AmountMg,
-		IngestedAt:  time.Now(),
+		UserID:      userID,
+		DoseID:      dose.ID,
+		SubstanceID: dose.SubstanceID,
+		AmountMg:    dose.AmountMg,
+		Reason:      strings.TrimSpace(reason),
+		At:          dose.IngestedAt,
+	}
+	for _, c := range severe {
+		entry.Conflicts = append(entry.Conflicts, c.Summary())
 	}
 
-	// Save to Store
-	h.Store.AddDose(req.UserID, dose)
+	h.Audit.Record(entry)
+	log.Printf("🚨 Override by [%s]: %s despite %d dangerous conflict(s). Reason: %s",
+		userID, dose.SubstanceID, len(severe), entry.Reason)
+	return entry.ID
+}
 
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(`{"status": "ingested", "message": "Dose tracked successfully"}`))
+// forUser returns the advisor and catalog as 'userID' sees them,
+// i.e. with their private definitions layered on top.
+func (h *Handler) forUser(userID string) (*engine.Advisor, repository.Repository) {
+	layered, ok := h.Repo.(repository.Layered)
+	if !ok || userID == "" {
+		return h.Advisor, h.Repo
+	}
+	repo := layered.ForUser(userID)
+	return h.Advisor.WithRepository(repo), repo
+}
+
+// writeJSON sends 'body' with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// ProductsEndpoint lists the product catalog (GET /products).
+func (h *Handler) ProductsEndpoint(w http.ResponseWriter, r *http.Request) {
+	products := h.Products.All()
+	if products == nil {
+		products = []domain.Product{}
+	}
+	writeJSON(w, http.StatusOK, products)
 }
 
 // -------------------------------------------------------------------------
@@ -152,14 +682,18 @@ func (h *Handler) StatusEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 1. Get the raw stack
+	advisor, repo := h.forUser(userID)
+	profile := h.Profiles.GetProfile(userID)
 	stack := h.Store.GetStack(userID)
 	var response []StatusResponse
 	now := time.Now()
 
 	// 2. Iterate and Calculate Decay
+	var defs []domain.SubstanceDefinition // Parallel to response
+	totals := make(map[string]float64)
 	for _, dose := range stack {
 		// Fetch scientific data (Half-Life)
-		def, err := h.Repo.GetDefinition(dose.SubstanceID)
+		def, err := repo.GetDefinition(dose.SubstanceID)
 		if err != nil {
 			continue // Skip unknown substances
 		}
@@ -168,15 +702,277 @@ func (h *Handler) StatusEndpoint(w http.ResponseWriter, r *http.Request) {
 
 		// THE MATH: Calculate remaining amount
 		remaining := h.Calc.RemainingAmount(dose.AmountMg, def.HalfLifeHours, elapsed)
+		totals[def.ID] += remaining
+		defs = append(defs, def)
+
+		// Show it as labelled when the user prefers that (e.g., a salt form in IU)
+		current := dose
+		current.AmountMg = remaining
+		if dose.AmountMg > 0 {
+			current.LabelledMg = dose.LabelledMg * remaining / dose.AmountMg
+		}
 
 		response = append(response, StatusResponse{
 			Substance:   def.Name,
 			OriginalMg:  dose.AmountMg,
 			CurrentMg:   remaining,
+			Display:     advisor.DisplayDose(current, profile),
 			TimeElapsed: elapsed.Round(time.Minute).String(),
 		})
 	}
 
+	// 3. Label each substance's combined amount against its therapeutic window
+	for i, def := range defs {
+		window := h.Calc.ClassifyWindow(def, totals[def.ID])
+		response[i].TotalMg = totals[def.ID]
+		response[i].Window = window.Label
+		response[i].NextWindow = window.NextLabel
+		if window.ChangesIn > 0 {
+			response[i].WindowChangesIn = window.ChangesIn.Round(time.Minute).String()
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// -------------------------------------------------------------------------
+// Endpoint 4: Synergy Suggestions (GET /suggest)
+// -------------------------------------------------------------------------
+
+func (h *Handler) SuggestEndpoint(w http.ResponseWriter, r *http.Request) {
+	substanceID := r.URL.Query().Get("substance_id")
+	if substanceID == "" {
+		http.Error(w, "substance_id required", http.StatusBadRequest)
+		return
+	}
+
+	// Optional comma-separated regimen (e.g., ?regimen=vitamin-c,nac)
+	var regimen []string
+	if raw := r.URL.Query().Get("regimen"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				regimen = append(regimen, id)
+			}
+		}
+	}
+
+	// Salt forms share their moiety's partners
+	substanceID, _, err := h.Advisor.ActiveAmount(substanceID, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	suggestions, err := h.Advisor.Suggest(substanceID, regimen)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 5: User Profile (GET/PUT /profile)
+// -------------------------------------------------------------------------
+
+func (h *Handler) GetProfileEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id required", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.Profiles.GetProfile(userID))
+}
+
+func (h *Handler) PutProfileEndpoint(w http.ResponseWriter, r *http.Request) {
+	var profile domain.UserProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if profile.UserID == "" {
+		http.Error(w, "user_id required", http.StatusBadRequest)
+		return
+	}
+
+	// Reject bad settings now rather than on every later request
+	if _, err := engine.UserLocation(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, rule := range profile.TimeRules {
+		if err := engine.ValidateTimeRule(rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if profile.Bedtime != "" {
+		if _, err := engine.NextClockTime(profile.Bedtime, time.Now(), time.UTC); err != nil {
+			http.Error(w, "bedtime: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := engine.ValidateStrictness(profile.Strictness); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for id, unit := range profile.PreferredUnits {
+		parsed, err := domain.ParseUnit(string(unit))
+		if err != nil {
+			http.Error(w, "preferred_units["+id+"]: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		profile.PreferredUnits[id] = parsed
+	}
+
+	h.Profiles.SetProfile(profile)
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 6: Latest Intake Before Bed (GET /cutoff)
+// -------------------------------------------------------------------------
+
+func (h *Handler) CutoffEndpoint(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	userID, substanceID := q.Get("user_id"), q.Get("substance_id")
+	if userID == "" || substanceID == "" {
+		http.Error(w, "user_id and substance_id required", http.StatusBadRequest)
+		return
+	}
+
+	amount, err := strconv.ParseFloat(q.Get("amount_mg"), 64)
+	if err != nil || amount <= 0 {
+		http.Error(w, "amount_mg must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	// Bedtime and threshold come from the query, falling back to the profile
+	profile := h.Profiles.GetProfile(userID)
+	bedtime := profile.Bedtime
+	if v := q.Get("bedtime"); v != "" {
+		bedtime = v
+	}
+	threshold := profile.SleepThresholdMg
+	if v := q.Get("threshold_mg"); v != "" {
+		if threshold, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "threshold_mg must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	if bedtime == "" || threshold <= 0 {
+		http.Error(w, "bedtime and threshold_mg required (query or profile)", http.StatusBadRequest)
+		return
+	}
+
+	// Resolve the next bedtime on the user's own clock
+	loc, err := engine.UserLocation(profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	bed, err := engine.NextClockTime(bedtime, now, loc)
+	if err != nil {
+		http.Error(w, "bedtime: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	advisor, _ := h.forUser(userID)
+	if substanceID, amount, err = advisor.ActiveAmount(substanceID, amount); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cutoff, err := advisor.LatestIntake(h.Store.GetStack(userID), substanceID, amount, threshold, bed, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cutoff)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 8: Washout Planner (POST /washout)
+// -------------------------------------------------------------------------
+
+// WashoutRequest asks when the user can safely switch to 'target_id'.
+type WashoutRequest struct {
+	UserID    string                    `json:"user_id"`
+	TargetID  string                    `json:"target_id"`
+	Criterion engine.ClearanceCriterion `json:"criterion"`          // Defaults to 5 half-lives
+	StartAt   string                    `json:"start_at,omitempty"` // RFC3339 planned start; defaults to the earliest possible
+}
+
+func (h *Handler) WashoutEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req WashoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" || req.TargetID == "" {
+		http.Error(w, "user_id and target_id required", http.StatusBadRequest)
+		return
+	}
+
+	var startAt time.Time
+	if req.StartAt != "" {
+		var err error
+		if startAt, err = time.Parse(time.RFC3339, req.StartAt); err != nil {
+			http.Error(w, "Invalid time format (use RFC3339): "+req.StartAt, http.StatusBadRequest)
+			return
+		}
+	}
+
+	advisor, repo := h.forUser(req.UserID)
+	targetID, _, err := advisor.ActiveAmount(req.TargetID, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	plan, err := advisor.PlanWashout(knownDoses(repo, h.Store.GetStack(req.UserID)), targetID, req.Criterion, h.Profiles.GetProfile(req.UserID), startAt, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 9: Override Audit Trail (GET /audit)
+// -------------------------------------------------------------------------
+
+func (h *Handler) AuditEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id required", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.Audit.GetEntries(userID))
+}
+
+// -------------------------------------------------------------------------
+// Endpoint 10: Catalog Reload (POST /admin/reload)
+// -------------------------------------------------------------------------
+
+func (h *Handler) ReloadEndpoint(w http.ResponseWriter, r *http.Request) {
+	reloader, ok := h.Repo.(repository.Reloader)
+	if !ok {
+		http.Error(w, "repository does not support reloading", http.StatusNotImplemented)
+		return
+	}
+
+	diff, err := reloader.Reload()
+	repository.LogReload(diff, err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
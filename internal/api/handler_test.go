@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+	"github.com/sitanshunandan/glate/internal/engine"
+	"github.com/sitanshunandan/glate/internal/repository"
+	"github.com/sitanshunandan/glate/internal/store"
+)
+
+// testCatalog has one DANGEROUS pair (dxm -> ssri) and one lesser one (caffeine -> iron).
+const testCatalog = `[
+	{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99, "max_daily_mg": 400,
+	 "interactions": [{"target_id": "iron", "type": "INHIBIT", "window_hours": 2, "note": "Blocks iron uptake."}]},
+	{"id": "iron", "name": "Iron", "category": "Mineral", "half_life_hours": 6, "bioavailability": 0.9, "max_daily_mg": 45, "interactions": []},
+	{"id": "vitamin-c", "name": "Vitamin C", "category": "Vitamin", "half_life_hours": 2, "bioavailability": 1, "interactions": []},
+	{"id": "calcium", "name": "Calcium", "category": "Mineral", "half_life_hours": 6, "bioavailability": 0.3, "interactions": []},
+	{"id": "calcium-carbonate", "name": "Calcium Carbonate", "category": "Mineral", "active_moiety_id": "calcium", "active_fraction": 0.4, "interactions": []},
+	{"id": "ssri", "name": "SSRI", "category": "Medication", "half_life_hours": 24, "bioavailability": 0.8, "interactions": []},
+	{"id": "dxm", "name": "DXM", "category": "Nootropic", "half_life_hours": 4, "bioavailability": 0.6,
+	 "interactions": [{"target_id": "ssri", "type": "DANGEROUS", "window_hours": 24, "note": "Risk of Serotonin Syndrome."}]}
+]`
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	repo, err := repository.NewInMemoryRepo("", repository.WithEmbedded([]byte(testCatalog)))
+	if err != nil {
+		t.Fatalf("test catalog: %v", err)
+	}
+	calc := engine.NewMetabolicCalculator()
+	return NewHandler(engine.NewAdvisor(repo, calc), store.NewSessionStore(), store.NewProfileStore(), store.NewAuditLog(), repo, calc)
+}
+
+// serve runs one request through an endpoint.
+func serve(endpoint http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	endpoint(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("decode response (%d): %v", rec.Code, err)
+	}
+	return v
+}
+
+func TestIngestBlocksDangerousUnlessOverridden(t *testing.T) {
+	h := newTestHandler(t)
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "ssri", "amount_mg": 20}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the first dose to be ingested, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// 1. No override: blocked, nothing stored
+	rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "dxm", "amount_mg": 10}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected 409, got %d: %s", rec.Code, rec.Body)
+	}
+	resp := decode[IngestResponse](t, rec)
+	if resp.Status != "blocked" || len(resp.Conflicts) != 1 || resp.DoseID != "" {
+		t.Errorf("Expected a blocked response with one conflict and no dose ID, got %+v", resp)
+	}
+
+	// 2. A whitespace-only reason is no reason
+	rec = serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "dxm", "amount_mg": 10, "override": {"reason": "   "}}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected a blank reason to stay blocked, got %d", rec.Code)
+	}
+	if stack := h.Store.GetStack("u1"); len(stack) != 1 {
+		t.Errorf("Expected blocked doses not to be stored, got %d doses", len(stack))
+	}
+
+	// 3. A real reason goes through and leaves an audit entry
+	rec = serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "dxm", "amount_mg": 10, "override": {"reason": " prescribed by my doctor "}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the override to be ingested, got %d: %s", rec.Code, rec.Body)
+	}
+	resp = decode[IngestResponse](t, rec)
+	if resp.AuditID == "" || resp.DoseID == "" {
+		t.Errorf("Expected a dose ID and an audit ID, got %+v", resp)
+	}
+
+	// 4. The trail is readable, per user
+	rec = serve(h.AuditEndpoint, "GET", "/audit?user_id=u1", "")
+	entries := decode[[]domain.AuditEntry](t, rec)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one audit entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ID != resp.AuditID || entry.DoseID != resp.DoseID || entry.SubstanceID != "dxm" || entry.Reason != "prescribed by my doctor" || len(entry.Conflicts) != 1 {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+	if others := decode[[]domain.AuditEntry](t, serve(h.AuditEndpoint, "GET", "/audit?user_id=u2", "")); len(others) != 0 {
+		t.Errorf("Expected no entries for another user, got %d", len(others))
+	}
+}
+
+func TestIngestReportsLesserConflictsAsWarnings(t *testing.T) {
+	h := newTestHandler(t)
+	serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "caffeine", "amount_mg": 100}`)
+
+	rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "iron", "amount_mg": 25}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected an INHIBIT conflict not to block, got %d: %s", rec.Code, rec.Body)
+	}
+	resp := decode[IngestResponse](t, rec)
+	if len(resp.Conflicts) != 0 || len(resp.Warnings) != 1 || resp.Warnings[0].Type != domain.TypeInhibit {
+		t.Errorf("Expected one INHIBIT warning, got conflicts %v, warnings %v", resp.Conflicts, resp.Warnings)
+	}
+	if resp.AuditID != "" || len(h.Audit.GetEntries("u1")) != 0 {
+		t.Error("Expected no audit entry without an override")
+	}
+}
+
+func TestIngestProducts(t *testing.T) {
+	h := newTestHandler(t)
+	h.Products = repository.NewProductCatalog([]domain.Product{
+		{ID: "mocha-iron", Name: "Mocha + Iron", Components: []domain.ProductComponent{
+			{SubstanceID: "caffeine", AmountMg: 80},
+			{SubstanceID: "iron", AmountMg: 15},
+		}},
+		{ID: "empty-box", Name: "Empty Box"}, // Lint only warns outside strict mode
+	})
+
+	// 1. Servings scale every component, and the doses share one intake ID
+	rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "product_id": "mocha-iron", "servings": 2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	resp := decode[IngestResponse](t, rec)
+	if len(resp.Components) != 2 || resp.Components[0].AmountMg != 160 || resp.Components[1].AmountMg != 30 {
+		t.Fatalf("Expected 160mg caffeine and 30mg iron, got %+v", resp.Components)
+	}
+	stack := h.Store.GetStack("u1")
+	if len(stack) != 2 || resp.ProductIngestID == "" || stack[0].ProductIngestID != resp.ProductIngestID || stack[1].ProductIngestID != resp.ProductIngestID {
+		t.Errorf("Expected both doses to share intake %q, got %+v", resp.ProductIngestID, stack)
+	}
+
+	// Components are one formulation: caffeine's iron rule doesn't fire within it
+	if len(resp.Components[1].Warnings) != 0 {
+		t.Errorf("Expected no warnings between components, got %v", resp.Components[1].Warnings)
+	}
+
+	// 2. All or nothing: iron (30 + 30 > 45mg/day) refuses the caffeine too
+	rec = serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "product_id": "mocha-iron", "servings": 2}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", rec.Code, rec.Body)
+	}
+	resp = decode[IngestResponse](t, rec)
+	if resp.Status != "refused" || !resp.Components[1].Limit.ExceedsDaily || resp.Components[0].DoseID != "" {
+		t.Errorf("Expected the iron cap to refuse the intake, got %+v", resp)
+	}
+	if stack := h.Store.GetStack("u1"); len(stack) != 2 {
+		t.Errorf("Expected no component of a refused intake to be stored, got %d doses", len(stack))
+	}
+
+	// 3. Bad requests
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "product_id": "mocha-iron", "servings": -1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for negative servings, got %d", rec.Code)
+	}
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "product_id": "energy-drink"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown product, got %d", rec.Code)
+	}
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "product_id": "empty-box"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a product without components, got %d", rec.Code)
+	}
+}
+
+func TestAnalyzeSingleKeepsTopLevelVerdict(t *testing.T) {
+	h := newTestHandler(t)
+
+	// A single proposed_id keeps the pre-batch fields at the top level
+	resp := decode[AnalysisResponse](t, serve(h.AnalyzeEndpoint, "POST", "/analyze", `{"proposed_id": "caffeine", "proposed_mg": 500}`))
+	if resp.Limit == nil || !resp.Limit.ExceedsDaily || len(resp.Items) != 1 || resp.Limit.ProposedMg != resp.Items[0].Limit.ProposedMg {
+		t.Errorf("Expected the item's limit at the top level, got %+v", resp)
+	}
+
+	// Batches only report per item
+	resp = decode[AnalysisResponse](t, serve(h.AnalyzeEndpoint, "POST", "/analyze", `{"proposed": [{"substance_id": "caffeine", "amount_mg": 100}]}`))
+	if resp.Limit != nil || len(resp.Items) != 1 {
+		t.Errorf("Expected no top-level limit for a batch, got %+v", resp)
+	}
+}
+
+func TestMatrixFormats(t *testing.T) {
+	h := newTestHandler(t)
+	body := `{"substance_ids": ["caffeine", "iron"]}`
+
+	rec := serve(h.MatrixEndpoint, "POST", "/matrix?format=csv", body)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("Expected CSV, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	want := ",Caffeine,Iron\nCaffeine,,INHIBIT 2h\nIron,INHIBIT 2h (reverse),\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("Unexpected CSV:\n%s\nwant:\n%s", got, want)
+	}
+
+	rec = serve(h.MatrixEndpoint, "POST", "/matrix?format=html", body)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<td title="Blocks iron uptake.">INHIBIT 2h</td>`) {
+		t.Errorf("Unexpected HTML (%d): %s", rec.Code, rec.Body)
+	}
+
+	if rec := serve(h.MatrixEndpoint, "POST", "/matrix?format=xml", body); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
+
+func TestIngestResolvesPrivateAliases(t *testing.T) {
+	h := newTestHandler(t)
+	layered := h.Repo.(repository.Layered)
+	entry := `{"id": "my-blend", "name": "My Blend", "aliases": ["morning mix"], "category": "Nootropic", "half_life_hours": 3, "bioavailability": 0.5, "interactions": []}`
+	if _, err := layered.SetUserDefinition("u1", json.RawMessage(entry)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "Morning Mix", "amount_mg": 100}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the private alias to resolve, got %d: %s", rec.Code, rec.Body)
+	}
+	if resp := decode[IngestResponse](t, rec); resp.SubstanceID != "my-blend" {
+		t.Errorf("Expected my-blend, got %q", resp.SubstanceID)
+	}
+
+	// Other users don't see it
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u2", "substance_id": "Morning Mix", "amount_mg": 100}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for another user, got %d", rec.Code)
+	}
+}
+
+func TestIngestAmountsWithUnits(t *testing.T) {
+	h := newTestHandler(t)
+	h.Profiles.SetProfile(domain.UserProfile{UserID: "u1", PreferredUnits: map[string]domain.Unit{"calcium-carbonate": domain.UnitG}})
+
+	// The preference for the labelled salt form is used, in labelled amounts
+	rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "calcium-carbonate", "amount": "1250 mg"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	resp := decode[IngestResponse](t, rec)
+	if resp.SubstanceID != "calcium" || resp.AmountMg != 500 || resp.Display == nil || *resp.Display != (domain.Amount{Value: 1.25, Unit: domain.UnitG}) {
+		t.Errorf("Expected 500mg calcium shown as 1.25 g, got %+v (display %v)", resp.DoseResult, resp.Display)
+	}
+
+	// So is /status, for what is left of the dose
+	status := decode[[]StatusResponse](t, serve(h.StatusEndpoint, "GET", "/status?user_id=u1", ""))
+	if len(status) != 1 || status[0].Display == nil || status[0].Display.Unit != domain.UnitG || status[0].Display.Value < 1.24 || status[0].Display.Value > 1.25 {
+		t.Errorf("Expected the remaining calcium in labelled grams, got %+v", status)
+	}
+
+	// Callers get their own copy of the preferences
+	h.Profiles.GetProfile("u1").PreferredUnits["calcium-carbonate"] = domain.UnitMcg
+	if unit := h.Profiles.GetProfile("u1").PreferredUnits["calcium-carbonate"]; unit != domain.UnitG {
+		t.Errorf("Expected the stored preference to be unchanged, got %q", unit)
+	}
+
+	// Both forms at once are ambiguous
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "iron", "amount_mg": 10, "amount": "20 mg"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for amount and amount_mg together, got %d", rec.Code)
+	}
+	if rec := serve(h.AnalyzeEndpoint, "POST", "/analyze", `{"proposed_id": "iron", "proposed_mg": 10, "proposed_amount": "20 mg"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for proposed_amount and proposed_mg together, got %d", rec.Code)
+	}
+}
+
+func TestIngestSkipsDosesOfRemovedSubstances(t *testing.T) {
+	h := newTestHandler(t)
+	layered := h.Repo.(repository.Layered)
+	entry := `{"id": "house-blend", "name": "House Blend", "category": "Nootropic", "half_life_hours": 3, "bioavailability": 0.5, "interactions": []}`
+	if _, err := layered.SetUserDefinition("u1", json.RawMessage(entry)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "house-blend", "amount_mg": 100}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected the private substance to be ingested, got %d: %s", rec.Code, rec.Body)
+	}
+	if err := layered.DeleteUserDefinition("u1", "house-blend"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The stored dose outlives its definition, but mustn't lock the user out
+	if rec := serve(h.IngestEndpoint, "POST", "/ingest", `{"user_id": "u1", "substance_id": "iron", "amount_mg": 10}`); rec.Code != http.StatusCreated {
+		t.Errorf("Expected ingest to skip the orphaned dose, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h.WashoutEndpoint, "POST", "/washout", `{"user_id": "u1", "target_id": "dxm"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected washout to skip the orphaned dose, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/sitanshunandan/glate/internal/engine"
+)
+
+// MatrixRequest lists the substances to cross-check.
+type MatrixRequest struct {
+	SubstanceIDs []string `json:"substance_ids"`
+}
+
+// matrixHTML renders a pasteable review table. The note is kept as a tooltip.
+var matrixHTML = template.Must(template.New("matrix").Parse(`<table>
+  <tr><th></th>{{range .Names}}<th>{{.}}</th>{{end}}</tr>
+{{- range $i, $row := .Cells}}
+  <tr><th>{{index $.Names $i}}</th>{{range $row}}<td title="{{.Note}}">{{.Label}}</td>{{end}}</tr>
+{{- end}}
+</table>
+`))
+
+// -------------------------------------------------------------------------
+// Endpoint 7: Interaction Matrix (POST /matrix?format=json|csv|html)
+// -------------------------------------------------------------------------
+
+func (h *Handler) MatrixEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req MatrixRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.SubstanceIDs) == 0 {
+		http.Error(w, "substance_ids required", http.StatusBadRequest)
+		return
+	}
+
+	// Salt forms are shown as the moiety their interactions belong to
+	ids := make([]string, len(req.SubstanceIDs))
+	for i, id := range req.SubstanceIDs {
+		var err error
+		if ids[i], _, err = h.Advisor.ActiveAmount(id, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	matrix, err := h.Advisor.BuildMatrix(ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Render into a buffer first, so a failure is a 500 rather than a truncated 200
+	var body []byte
+	var contentType string
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, matrix)
+		return
+	case "csv":
+		contentType = "text/csv"
+		body, err = renderMatrixCSV(matrix)
+	case "html":
+		contentType = "text/html; charset=utf-8"
+		var buf bytes.Buffer
+		err = matrixHTML.Execute(&buf, matrix)
+		body = buf.Bytes()
+	default:
+		http.Error(w, "format must be json, csv or html", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Matrix render failed: %v", err)
+		http.Error(w, "failed to render matrix", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("❌ Matrix write failed: %v", err)
+	}
+}
+
+// renderMatrixCSV writes a header row of names, then one row per substance.
+func renderMatrixCSV(matrix engine.Matrix) ([]byte, error) {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	if err := out.Write(append([]string{""}, matrix.Names...)); err != nil {
+		return nil, err
+	}
+	for i, row := range matrix.Cells {
+		record := []string{matrix.Names[i]}
+		for _, cell := range row {
+			record = append(record, cell.Label())
+		}
+		if err := out.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	out.Flush()
+	return buf.Bytes(), out.Error()
+}
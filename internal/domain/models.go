@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // InteractionType defines the nature of the relationship between two compounds.
 // We use string types for better readability in JSON/DBs.
@@ -17,6 +20,20 @@ const (
 	TypeDangerous InteractionType = "DANGEROUS"
 )
 
+// EvidenceLevel grades how well an interaction is supported, strongest first.
+// Unset means established, so ungraded catalogs keep their full weight.
+type EvidenceLevel string
+
+const (
+	EvidenceEstablished EvidenceLevel = "established" // Controlled studies or official labelling
+	EvidenceProbable    EvidenceLevel = "probable"    // Consistent case reports with a known mechanism
+	EvidenceTheoretical EvidenceLevel = "theoretical" // Mechanism or in-vitro data only
+	EvidenceAnecdotal   EvidenceLevel = "anecdotal"   // User reports
+)
+
+// KnownEvidenceLevels lists every evidence level, strongest first.
+var KnownEvidenceLevels = []EvidenceLevel{EvidenceEstablished, EvidenceProbable, EvidenceTheoretical, EvidenceAnecdotal}
+
 // SubstanceCategory helps UI/Logic group items (e.g., "Don't take stimulants after 4 PM").
 type SubstanceCategory string
 
@@ -28,27 +45,127 @@ const (
 	CatAminoAcid SubstanceCategory = "AminoAcid"
 )
 
+// KnownCategories lists every category the engine understands.
+var KnownCategories = []SubstanceCategory{CatMineral, CatVitamin, CatStimulant, CatNootropic, CatAminoAcid}
+
+// KnownInteractionTypes lists every edge type the engine understands.
+var KnownInteractionTypes = []InteractionType{TypeInhibit, TypePotentiate, TypeDangerous}
+
 // -------------------------------------------------------------------------
 // Static Definitions (The "Public Database" Data)
 // -------------------------------------------------------------------------
 
 // Interaction represents a rule: "If you take X, be careful with TargetID".
+// A rule can target a whole class instead (TargetClass, e.g., every "ssri").
+// The optional dose fields let a rule only fire above a certain amount
+// (e.g., a little Calcium barely touches Iron, 1000mg does).
 type Interaction struct {
-	TargetID    string          `json:"target_id"`    // The ID of the *other* substance
-	Type        InteractionType `json:"type"`         // INHIBIT, POTENTIATE, DANGEROUS
-	WindowHours float64         `json:"window_hours"` // How long the interaction lasts (clearance window)
-	Note        string          `json:"note"`         // Clinical explanation (e.g., "Competes for DMT1 transporter")
+	TargetID    string          `json:"target_id,omitempty"`    // The ID of the *other* substance
+	TargetClass string          `json:"target_class,omitempty"` // Or: any substance in this class or category
+	Type        InteractionType `json:"type"`                   // INHIBIT, POTENTIATE, DANGEROUS
+	WindowHours float64         `json:"window_hours"`           // How long the interaction lasts (clearance window)
+	Note        string          `json:"note"`                   // Clinical explanation (e.g., "Competes for DMT1 transporter")
+	Evidence    EvidenceLevel   `json:"evidence,omitempty"`     // How well supported the rule is (empty = established)
+
+	MinSourceMg    float64 `json:"min_source_mg,omitempty"`    // Rule ignored if the owning substance's dose is below this
+	MinTargetMg    float64 `json:"min_target_mg,omitempty"`    // Rule ignored if the target's dose is below this
+	WindowScaleMg  float64 `json:"window_scale_mg,omitempty"`  // If set, WindowHours applies at this dose and scales linearly with the owner's dose
+	MaxWindowHours float64 `json:"max_window_hours,omitempty"` // Upper bound for a scaled window (0 = no cap)
+
+	References map[string][]Reference `json:"references,omitempty"` // Parameter (JSON field, e.g. "window_hours") -> sources
+}
+
+// Target names what the rule points at: the substance ID, or "class:<name>".
+func (i Interaction) Target() string {
+	if i.TargetClass != "" {
+		return "class:" + i.TargetClass
+	}
+	return i.TargetID
+}
+
+// Reference is one published source behind a catalog parameter.
+type Reference struct {
+	Citation   string `json:"citation"`             // Human-readable: authors, title, journal, year
+	SourceID   string `json:"source_id,omitempty"`  // DOI or PMID, e.g. "doi:10.1000/xyz123" or "pmid:12345678"
+	Population string `json:"population,omitempty"` // Who was studied, e.g. "healthy adults, n=12"
+	Grade      string `json:"grade,omitempty"`      // Evidence grade as given by the source, e.g. "A", "low"
 }
 
 // SubstanceDefinition is the immutable science data.
 // It comes from your JSON seeder or DB.
 type SubstanceDefinition struct {
-	ID              string            `json:"id"`              // Unique slug (e.g., "magnesium-glycinate")
-	Name            string            `json:"name"`            // Display name
-	Category        SubstanceCategory `json:"category"`        // e.g., Mineral
-	HalfLifeHours   float64           `json:"half_life_hours"` // e.g., 4.0
-	Bioavailability float64           `json:"bioavailability"` // 0.0 to 1.0 (Absorption efficiency)
-	Interactions    []Interaction     `json:"interactions"`    // The graph edges (dependencies)
+	ID              string            `json:"id"`                // Unique slug (e.g., "magnesium-glycinate")
+	Name            string            `json:"name"`              // Display name
+	Aliases         []string          `json:"aliases,omitempty"` // Synonyms users type (e.g., "ascorbic acid", "vit c")
+	Category        SubstanceCategory `json:"category"`          // e.g., Mineral
+	Classes         []string          `json:"classes,omitempty"` // Classes rules can target (e.g., "ssri", "maoi")
+	HalfLifeHours   float64           `json:"half_life_hours"`   // e.g., 4.0
+	Bioavailability float64           `json:"bioavailability"`   // 0.0 to 1.0 (Absorption efficiency)
+	Interactions    []Interaction     `json:"interactions"`      // The graph edges (dependencies)
+
+	// Salt forms (e.g., "magnesium-glycinate"): amounts are as labelled, and ActiveFraction
+	// of them is ActiveMoietyID (e.g., "magnesium"). Kinetics, limits and interactions all
+	// come from the moiety, so a salt form only needs a name and the conversion.
+	ActiveMoietyID string  `json:"active_moiety_id,omitempty"`
+	ActiveFraction float64 `json:"active_fraction,omitempty"` // Active mass per labelled mass (0 = 1)
+
+	Conversions []UnitConversion `json:"conversions,omitempty"` // Non-mass units (e.g., 1 IU = 0.025 mcg for vitamin D)
+
+	MinEffectiveMg float64 `json:"min_effective_mg,omitempty"` // Active amount below which there is no useful effect
+	ToxicMg        float64 `json:"toxic_mg,omitempty"`         // Active amount at which side effects dominate
+
+	MaxDailyMg      float64 `json:"max_daily_mg,omitempty"`       // Tolerable intake per rolling 24h (0 = no limit)
+	MaxSingleDoseMg float64 `json:"max_single_dose_mg,omitempty"` // Largest dose allowed at once (0 = no limit)
+
+	Metabolites       []Metabolite       `json:"metabolites,omitempty"`       // Active compounds the body turns this into
+	Contraindications []Contraindication `json:"contraindications,omitempty"` // Health conditions / medications to avoid
+	Loads             map[string]float64 `json:"loads,omitempty"`             // Load axis ID -> weight per active mg (e.g., "stimulant": 1.0)
+
+	References map[string][]Reference `json:"references,omitempty"` // Parameter (JSON field, e.g. "half_life_hours") -> sources
+}
+
+// InClass reports whether the substance belongs to 'class', by its classes or
+// its category. Class names are matched case-insensitively.
+func (d SubstanceDefinition) InClass(class string) bool {
+	class = strings.TrimSpace(class)
+	if class == "" {
+		return false
+	}
+	if strings.EqualFold(string(d.Category), class) {
+		return true
+	}
+	for _, c := range d.Classes {
+		if strings.EqualFold(strings.TrimSpace(c), class) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contraindication is a rule against a user's health condition or long-term medication,
+// rather than another catalog substance. Type carries the severity: DANGEROUS blocks, the rest warn.
+type Contraindication struct {
+	Condition  string          `json:"condition,omitempty"`  // e.g., "hypertension", "pregnancy"
+	Medication string          `json:"medication,omitempty"` // e.g., "anticoagulants"
+	Type       InteractionType `json:"type"`
+	Note       string          `json:"note"`
+	Evidence   EvidenceLevel   `json:"evidence,omitempty"` // Empty = established
+}
+
+// Metabolite links a parent substance to a catalog substance it is converted into.
+// The metabolite then clears with its own half-life.
+type Metabolite struct {
+	SubstanceID string  `json:"substance_id"`
+	Fraction    float64 `json:"fraction"` // Share of the parent dose converted (0.0 to 1.0)
+}
+
+// LoadAxis is a named pharmacological burden that several substances add to
+// (e.g., total stimulant or serotonergic load), with the level worth warning about.
+type LoadAxis struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Threshold float64 `json:"threshold"` // Weighted load (sum of remaining mg x weight) that triggers a warning
+	Note      string  `json:"note,omitempty"`
 }
 
 // -------------------------------------------------------------------------
@@ -61,4 +178,81 @@ type ActiveDose struct {
 	SubstanceID string    // Links back to SubstanceDefinition.ID
 	AmountMg    float64   // How much was taken
 	IngestedAt  time.Time // Timestamp of ingestion
+
+	LabelledID string  // What the user took, when it was a salt form (e.g., "calcium-carbonate")
+	LabelledMg float64 // The labelled amount; AmountMg is the active moiety's share
+
+	ProductID       string // Set when the dose came from a product (e.g., "coffee-cup")
+	ProductIngestID string // Shared by every dose expanded from the same product intake
+}
+
+// -------------------------------------------------------------------------
+// Products (what people actually take)
+// -------------------------------------------------------------------------
+
+// Product is a formulation containing one or more catalog substances,
+// e.g. a cup of coffee or a multivitamin. Amounts are per serving.
+type Product struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Formulation string             `json:"formulation"`       // e.g., "beverage", "tablet", "capsule"
+	Serving     string             `json:"serving,omitempty"` // What one serving is (e.g., "1 cup (240 mL)")
+	Components  []ProductComponent `json:"components"`
+}
+
+// ProductComponent is one substance in a product.
+type ProductComponent struct {
+	SubstanceID string  `json:"substance_id"`
+	AmountMg    float64 `json:"amount_mg"` // Per serving
+}
+
+// -------------------------------------------------------------------------
+// Rules & Preferences
+// -------------------------------------------------------------------------
+
+// TimeRule restricts a category or a single substance to a time-of-day window,
+// evaluated on the user's local wall clock (e.g., "No stimulants after 16:00").
+// If NotBefore is later than NotAfter, the allowed window wraps past midnight.
+type TimeRule struct {
+	ID          string            `json:"id"`
+	Category    SubstanceCategory `json:"category,omitempty"`     // Applies to every substance in this category...
+	SubstanceID string            `json:"substance_id,omitempty"` // ...or to one substance
+	NotBefore   string            `json:"not_before,omitempty"`   // "HH:MM", earliest allowed time
+	NotAfter    string            `json:"not_after,omitempty"`    // "HH:MM", cutoff
+	Note        string            `json:"note,omitempty"`
+}
+
+// UserProfile holds per-user settings that shape the advice.
+type UserProfile struct {
+	UserID    string     `json:"user_id"`
+	Timezone  string     `json:"timezone,omitempty"`   // IANA name (e.g., "Europe/Berlin"); empty = server local
+	TimeRules []TimeRule `json:"time_rules,omitempty"` // Personal rules, checked alongside the catalog's
+
+	Bedtime          string  `json:"bedtime,omitempty"`            // "HH:MM" on the user's clock
+	SleepThresholdMg float64 `json:"sleep_threshold_mg,omitempty"` // Max load of a substance at bedtime (e.g., 50mg caffeine)
+
+	HalfLifeOverrides map[string]float64 `json:"half_life_overrides,omitempty"` // Personal half-lives by substance ID (e.g., slow caffeine metabolizer)
+
+	Conditions  []string `json:"conditions,omitempty"`  // Health conditions (e.g., "hypertension")
+	Medications []string `json:"medications,omitempty"` // Long-term medications: free text or catalog substance IDs
+
+	Strictness EvidenceLevel `json:"strictness,omitempty"` // Weakest evidence /analyze reports by default (empty = everything)
+
+	PreferredUnits map[string]Unit `json:"preferred_units,omitempty"` // Substance ID -> unit to show amounts in (e.g., "vitamin-d": "IU")
+}
+
+// -------------------------------------------------------------------------
+// Audit
+// -------------------------------------------------------------------------
+
+// AuditEntry records a dose that was ingested despite a severe warning.
+type AuditEntry struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	DoseID      string    `json:"dose_id"`
+	SubstanceID string    `json:"substance_id"`
+	AmountMg    float64   `json:"amount_mg"`
+	Reason      string    `json:"reason"`    // The user's stated justification
+	Conflicts   []string  `json:"conflicts"` // What was overridden, in readable form
+	At          time.Time `json:"at"`
 }
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Unit is a unit of amount as users and labels write it.
+type Unit string
+
+const (
+	UnitMg  Unit = "mg"
+	UnitMcg Unit = "mcg"
+	UnitG   Unit = "g"
+	UnitIU  Unit = "IU" // International Units: substance-specific (e.g., vitamin D)
+	UnitML  Unit = "mL" // Volume: substance-specific (e.g., a liquid extract)
+)
+
+// KnownUnits lists every unit the engine understands.
+var KnownUnits = []Unit{UnitMg, UnitMcg, UnitG, UnitIU, UnitML}
+
+// massInMg are the static conversions. Anything else needs a per-substance rule.
+var massInMg = map[Unit]float64{UnitMg: 1, UnitMcg: 0.001, UnitG: 1000}
+
+// IsMass reports whether the unit converts to mg without a per-substance rule.
+func (u Unit) IsMass() bool {
+	_, ok := massInMg[u]
+	return ok
+}
+
+// UnitConversion is a per-substance rule, e.g. vitamin D: 1 IU = 0.025 mcg.
+// 'To' must be a mass unit, so every amount can end up in mg.
+type UnitConversion struct {
+	From   Unit    `json:"from"`
+	To     Unit    `json:"to"`
+	Factor float64 `json:"factor"` // 1 From = Factor To
+}
+
+// Amount is a quantity with its unit. In JSON it is either an object
+// ({"value": 1000, "unit": "IU"}), a string ("1000 IU") or a bare number (mg).
+type Amount struct {
+	Value float64 `json:"value"`
+	Unit  Unit    `json:"unit"`
+}
+
+// Mg is a shorthand for an amount in milligrams.
+func Mg(v float64) Amount {
+	return Amount{Value: v, Unit: UnitMg}
+}
+
+func (a Amount) String() string {
+	return strconv.FormatFloat(a.Value, 'f', -1, 64) + " " + string(a.Unit)
+}
+
+// ParseUnit accepts the usual spellings ("MG", "µg", "ug", "iu", "ml").
+func ParseUnit(s string) (Unit, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "mg", "":
+		return UnitMg, nil
+	case "mcg", "µg", "μg", "ug":
+		return UnitMcg, nil
+	case "g":
+		return UnitG, nil
+	case "iu":
+		return UnitIU, nil
+	case "ml":
+		return UnitML, nil
+	}
+	return "", fmt.Errorf("unknown unit %q (want one of %v)", s, KnownUnits)
+}
+
+// ParseAmount reads "500mg", "1000 IU" or "2.5 g". A bare number is mg.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+' && r != 'e' && r != 'E'
+	})
+	number, unit := s, ""
+	if split >= 0 {
+		number, unit = s[:split], s[split:]
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	u, err := ParseUnit(unit)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: value, Unit: u}, nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := ParseAmount(text)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	}
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*a = Mg(number)
+		return nil
+	}
+
+	var obj struct {
+		Value float64 `json:"value"`
+		Unit  string  `json:"unit"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("amount must be a number (mg), a string like \"1000 IU\" or {\"value\", \"unit\"}")
+	}
+	unit, err := ParseUnit(obj.Unit)
+	if err != nil {
+		return err
+	}
+	*a = Amount{Value: obj.Value, Unit: unit}
+	return nil
+}
+
+// mgPerUnit is how many mg one 'unit' of the substance is. Mass units need no
+// definition; IU and mL need a conversion rule on it.
+func mgPerUnit(unit Unit, def *SubstanceDefinition) (float64, error) {
+	if f, ok := massInMg[unit]; ok {
+		return f, nil
+	}
+	if def != nil {
+		for _, conv := range def.Conversions {
+			if conv.From == unit {
+				if to, ok := massInMg[conv.To]; ok && conv.Factor > 0 {
+					return conv.Factor * to, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("%s has no conversion from %s", def.ID, unit)
+	}
+	return 0, fmt.Errorf("%s needs a substance-specific conversion", unit)
+}
+
+// ToMg converts the amount to milligrams. 'def' may be nil for mass units.
+func (a Amount) ToMg(def *SubstanceDefinition) (float64, error) {
+	f, err := mgPerUnit(a.Unit, def)
+	if err != nil {
+		return 0, err
+	}
+	return a.Value * f, nil
+}
+
+// AmountFromMg renders a milligram amount in 'unit'.
+func AmountFromMg(mg float64, unit Unit, def *SubstanceDefinition) (Amount, error) {
+	f, err := mgPerUnit(unit, def)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: mg / f, Unit: unit}, nil
+}
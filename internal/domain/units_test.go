@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{"500mg", Amount{500, UnitMg}, false},
+		{"1000 IU", Amount{1000, UnitIU}, false},
+		{"2.5 g", Amount{2.5, UnitG}, false},
+		{"50 µg", Amount{50, UnitMcg}, false},
+		{"50ug", Amount{50, UnitMcg}, false},
+		{"5 ML", Amount{5, UnitML}, false},
+		{"250", Amount{250, UnitMg}, false}, // A bare number is mg
+		{"3 tbsp", Amount{}, true},
+		{"lots", Amount{}, true},
+		{"", Amount{}, true},
+	}
+	for _, c := range cases {
+		got, err := ParseAmount(c.in)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("ParseAmount(%q) = %+v, %v; want %+v (error: %v)", c.in, got, err, c.want, c.wantErr)
+		}
+	}
+}
+
+func TestAmountUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{`"1000 IU"`, Amount{1000, UnitIU}, false},
+		{`750`, Amount{750, UnitMg}, false},
+		{`{"value": 2, "unit": "g"}`, Amount{2, UnitG}, false},
+		{`{"value": 400, "unit": "mcg"}`, Amount{400, UnitMcg}, false},
+		{`{"value": 400}`, Amount{400, UnitMg}, false}, // Missing unit means mg
+		{`{"value": 1, "unit": "cup"}`, Amount{}, true},
+		{`"a handful"`, Amount{}, true},
+		{`[1, 2]`, Amount{}, true},
+	}
+	for _, c := range cases {
+		var got Amount
+		err := json.Unmarshal([]byte(c.in), &got)
+		if (err != nil) != c.wantErr || (!c.wantErr && got != c.want) {
+			t.Errorf("Unmarshal(%s) = %+v, %v; want %+v (error: %v)", c.in, got, err, c.want, c.wantErr)
+		}
+	}
+}
+
+func TestAmountToMg(t *testing.T) {
+	vitD := &SubstanceDefinition{ID: "vitamin-d3", Conversions: []UnitConversion{{From: UnitIU, To: UnitMcg, Factor: 0.025}}}
+
+	if mg, err := (Amount{2.5, UnitG}).ToMg(nil); err != nil || mg != 2500 {
+		t.Errorf("Expected 2.5 g = 2500mg, got %g (%v)", mg, err)
+	}
+	if mg, err := (Amount{4000, UnitIU}).ToMg(vitD); err != nil || math.Abs(mg-0.1) > 1e-12 {
+		t.Errorf("Expected 4000 IU = 0.1mg, got %g (%v)", mg, err)
+	}
+	if _, err := (Amount{5, UnitML}).ToMg(vitD); err == nil {
+		t.Error("Expected mL without a conversion to fail")
+	}
+	if back, err := AmountFromMg(0.1, UnitIU, vitD); err != nil || math.Abs(back.Value-4000) > 1e-9 {
+		t.Errorf("Expected 0.1mg = 4000 IU, got %+v (%v)", back, err)
+	}
+}
@@ -15,12 +15,39 @@ type Conflict struct {
 	Type       domain.InteractionType
 	Reason     string
 	WaitTime   time.Duration // How long until it is safe
+	Direction  Direction     // Which side owns the rule
+	Evidence   domain.EvidenceLevel
+	Trace      *ConflictTrace // Full explanation of how the rule fired
+}
+
+// Severe reports whether the conflict should block a dose outright.
+func (c Conflict) Severe() bool {
+	return c.Type == domain.TypeDangerous
+}
+
+// Summary is a one-line description for logs and audit trails.
+func (c Conflict) Summary() string {
+	return fmt.Sprintf("[%s] %s -> %s: %s", c.Type, c.SubstanceA, c.SubstanceB, c.Reason)
+}
+
+// SplitSevere separates blocking conflicts from mere warnings.
+func SplitSevere(conflicts []Conflict) (severe, warnings []Conflict) {
+	for _, c := range conflicts {
+		if c.Severe() {
+			severe = append(severe, c)
+		} else {
+			warnings = append(warnings, c)
+		}
+	}
+	return severe, warnings
 }
 
 // Advisor orchestrates the safety checks.
 type Advisor struct {
-	repo repository.Repository
-	calc *MetabolicCalculator
+	repo      repository.Repository
+	calc      *MetabolicCalculator
+	timeRules []domain.TimeRule // Catalog-wide time-of-day rules
+	loadAxes  []domain.LoadAxis // Catalog-wide cumulative load thresholds
 }
 
 // NewAdvisor creates the analysis engine.
@@ -31,10 +58,24 @@ func NewAdvisor(repo repository.Repository, calc *MetabolicCalculator) *Advisor
 	}
 }
 
+// WithRepository returns a copy of the advisor reading from 'repo', e.g. a
+// user's view of the catalog with their private definitions. Rules and axes are shared.
+func (a *Advisor) WithRepository(repo repository.Repository) *Advisor {
+	clone := *a
+	clone.repo = repo
+	return &clone
+}
+
 // CheckSafety evaluates if 'newSubstanceID' can be taken given the 'activeStack'.
-func (a *Advisor) CheckSafety(activeStack []domain.ActiveDose, newSubstanceID string) ([]Conflict, error) {
+// 'proposedMg' is the amount about to be taken; pass 0 if unknown, in which
+// case dose thresholds are ignored and every matching rule fires.
+func (a *Advisor) CheckSafety(activeStack []domain.ActiveDose, newSubstanceID string, proposedMg float64) ([]Conflict, error) {
+	return a.CheckSafetyAt(activeStack, newSubstanceID, proposedMg, time.Now())
+}
+
+// CheckSafetyAt is CheckSafety for a dose taken at 'at' instead of now.
+func (a *Advisor) CheckSafetyAt(activeStack []domain.ActiveDose, newSubstanceID string, proposedMg float64, at time.Time) ([]Conflict, error) {
 	var conflicts []Conflict
-	now := time.Now()
 
 	// 1. Fetch metadata for the proposed substance
 	newDef, err := a.repo.GetDefinition(newSubstanceID)
@@ -52,38 +93,47 @@ func (a *Advisor) CheckSafety(activeStack []domain.ActiveDose, newSubstanceID st
 		}
 
 		// Calculate how long it has been in the system
-		elapsed := now.Sub(dose.IngestedAt)
+		elapsed := at.Sub(dose.IngestedAt)
+		remaining := a.calc.RemainingAmount(dose.AmountMg, activeDef.HalfLifeHours, elapsed)
 
 		// CHECK A: Does the ACTIVE substance hate the NEW one?
 		// e.g., Active Caffeine vs New Iron
-		if rule, found := a.findInteraction(activeDef, newSubstanceID); found {
-			// Is the window still open?
-			window := time.Duration(rule.WindowHours * float64(time.Hour))
+		if rule, found := a.findInteraction(activeDef, newDef); found && ruleApplies(rule, dose.AmountMg, proposedMg) {
+			// Is the window still open? (Scaled by the active dose if the rule asks for it)
+			window := ruleWindow(rule, dose.AmountMg)
 			if elapsed < window {
+				trace := buildTrace(DirActiveToProposed, activeDef.ID, newDef.ID, rule, dose, remaining, proposedMg, dose.AmountMg, elapsed, window)
 				conflicts = append(conflicts, Conflict{
 					SubstanceA: activeDef.Name,
 					SubstanceB: newDef.Name,
 					Type:       rule.Type,
 					Reason:     rule.Note,
 					WaitTime:   window - elapsed,
+					Direction:  DirActiveToProposed,
+					Evidence:   evidenceOf(rule.Evidence),
+					Trace:      &trace,
 				})
 			}
 		}
 
 		// CHECK B: Does the NEW substance hate the ACTIVE one?
 		// e.g., New DXM vs Active SSRI (Dangerous!)
-		if rule, found := a.findInteraction(newDef, dose.SubstanceID); found {
+		if rule, found := a.findInteraction(newDef, activeDef); found && ruleApplies(rule, proposedMg, dose.AmountMg) {
 			// For dangerous interactions, we might check if the active dose
 			// has effectively cleared (using the Calculator) rather than just a fixed window.
-			// For now, we use the fixed window from the new definition.
-			window := time.Duration(rule.WindowHours * float64(time.Hour))
+			// For now, we use the window from the new definition, scaled by the proposed dose.
+			window := ruleWindow(rule, proposedMg)
 			if elapsed < window {
+				trace := buildTrace(DirProposedToActive, newDef.ID, activeDef.ID, rule, dose, remaining, proposedMg, proposedMg, elapsed, window)
 				conflicts = append(conflicts, Conflict{
 					SubstanceA: activeDef.Name, // Still list the active one first for clarity
 					SubstanceB: newDef.Name,
 					Type:       rule.Type,
-					Reason:     "Reverse Conflict: " + rule.Note,
+					Reason:     rule.Note,
 					WaitTime:   window - elapsed,
+					Direction:  DirProposedToActive,
+					Evidence:   evidenceOf(rule.Evidence),
+					Trace:      &trace,
 				})
 			}
 		}
@@ -92,12 +142,56 @@ func (a *Advisor) CheckSafety(activeStack []domain.ActiveDose, newSubstanceID st
 	return conflicts, nil
 }
 
-// Helper to search the interaction slice (O(N) is fine here as N is small)
-func (a *Advisor) findInteraction(source domain.SubstanceDefinition, targetID string) (domain.Interaction, bool) {
+// Helper to search the interaction slice (O(N) is fine here as N is small).
+// A rule naming the target's ID wins over one matching its class.
+func (a *Advisor) findInteraction(source, target domain.SubstanceDefinition) (domain.Interaction, bool) {
 	for _, rule := range source.Interactions {
-		if rule.TargetID == targetID {
+		if rule.TargetID != "" && rule.TargetID == target.ID {
+			return rule, true
+		}
+	}
+	if source.ID == target.ID {
+		return domain.Interaction{}, false
+	}
+	for _, rule := range source.Interactions {
+		if rule.TargetClass != "" && target.InClass(rule.TargetClass) {
 			return rule, true
 		}
 	}
 	return domain.Interaction{}, false
 }
+
+// resolutionPath is how a rule reached 'targetID': owner -> target, or
+// owner -> class -> target for class-level rules.
+func resolutionPath(owner string, rule domain.Interaction, targetID string) []string {
+	if rule.TargetClass != "" {
+		return []string{owner, "class:" + rule.TargetClass, targetID}
+	}
+	return []string{owner, targetID}
+}
+
+// ruleApplies checks the optional dose thresholds of a rule.
+// sourceMg is the dose of the substance that owns the rule, targetMg the other side.
+// A zero amount means "unknown" and never suppresses the rule.
+func ruleApplies(rule domain.Interaction, sourceMg, targetMg float64) bool {
+	if rule.MinSourceMg > 0 && sourceMg > 0 && sourceMg < rule.MinSourceMg {
+		return false
+	}
+	if rule.MinTargetMg > 0 && targetMg > 0 && targetMg < rule.MinTargetMg {
+		return false
+	}
+	return true
+}
+
+// ruleWindow returns the clearance window for a rule given the owner's dose.
+// Without WindowScaleMg the window is fixed; otherwise it grows linearly with the dose.
+func ruleWindow(rule domain.Interaction, sourceMg float64) time.Duration {
+	hours := rule.WindowHours
+	if rule.WindowScaleMg > 0 && sourceMg > 0 {
+		hours = rule.WindowHours * (sourceMg / rule.WindowScaleMg)
+	}
+	if rule.MaxWindowHours > 0 && hours > rule.MaxWindowHours {
+		hours = rule.MaxWindowHours
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
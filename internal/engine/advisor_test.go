@@ -0,0 +1,556 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// stubRepo is a minimal in-memory Repository for engine tests.
+type stubRepo map[string]domain.SubstanceDefinition
+
+func (r stubRepo) GetDefinition(id string) (domain.SubstanceDefinition, error) {
+	def, ok := r[id]
+	if !ok {
+		return domain.SubstanceDefinition{}, fmt.Errorf("substance '%s' not found", id)
+	}
+	return def, nil
+}
+
+func (r stubRepo) GetAll() (map[string]domain.SubstanceDefinition, error) {
+	return r, nil
+}
+
+func newStubRepo() stubRepo {
+	return stubRepo{
+		"iron": {ID: "iron", Name: "Iron", HalfLifeHours: 6},
+		"vitamin-c": {ID: "vitamin-c", Name: "Vitamin C", HalfLifeHours: 2, Interactions: []domain.Interaction{
+			{TargetID: "iron", Type: domain.TypePotentiate, WindowHours: 0.5, Note: "Reduces ferric iron."},
+		}},
+		"lactoferrin": {ID: "lactoferrin", Name: "Lactoferrin", HalfLifeHours: 3, Interactions: []domain.Interaction{
+			{TargetID: "iron", Type: domain.TypePotentiate, WindowHours: 1, Note: "Carries iron."},
+		}},
+		"calcium": {ID: "calcium", Name: "Calcium", HalfLifeHours: 4, Interactions: []domain.Interaction{
+			{TargetID: "iron", Type: domain.TypeInhibit, WindowHours: 2, Note: "Competes for DMT1."},
+		}},
+	}
+}
+
+func TestSuggestRanksRegimenFirst(t *testing.T) {
+	advisor := NewAdvisor(newStubRepo(), NewMetabolicCalculator())
+
+	suggestions, err := advisor.Suggest("iron", []string{"vitamin-c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Calcium only inhibits, so it must not be suggested
+	if len(suggestions) != 2 {
+		t.Fatalf("Expected 2 suggestions, got %d", len(suggestions))
+	}
+	if suggestions[0].SubstanceID != "vitamin-c" || !suggestions[0].InRegimen {
+		t.Errorf("Expected regimen item first, got %+v", suggestions[0])
+	}
+	if suggestions[0].WindowMin != 30 {
+		t.Errorf("Expected 30 minute window, got %f", suggestions[0].WindowMin)
+	}
+}
+
+func TestCheckSafetyDoseThresholds(t *testing.T) {
+	repo := newStubRepo()
+	calcium := repo["calcium"]
+	calcium.Interactions[0].MinSourceMg = 300
+	calcium.Interactions[0].WindowScaleMg = 500
+	repo["calcium"] = calcium
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	taken := time.Now().Add(-30 * time.Minute)
+
+	// 100mg Calcium is below the threshold: no conflict
+	small := []domain.ActiveDose{{SubstanceID: "calcium", AmountMg: 100, IngestedAt: taken}}
+	conflicts, err := advisor.CheckSafety(small, "iron", 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts below threshold, got %d", len(conflicts))
+	}
+
+	// 1000mg Calcium doubles the 2h window to 4h, so ~3.5h remain
+	large := []domain.ActiveDose{{SubstanceID: "calcium", AmountMg: 1000, IngestedAt: taken}}
+	conflicts, err = advisor.CheckSafety(large, "iron", 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %d", len(conflicts))
+	}
+	if wait := conflicts[0].WaitTime.Hours(); wait < 3.4 || wait > 3.6 {
+		t.Errorf("Expected ~3.5h wait, got %f", wait)
+	}
+}
+
+func TestCheckLimitsRollingWindow(t *testing.T) {
+	repo := newStubRepo()
+	iron := repo["iron"]
+	iron.MaxDailyMg = 45
+	repo["iron"] = iron
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	now := time.Now()
+	history := []domain.ActiveDose{
+		{SubstanceID: "iron", AmountMg: 25, IngestedAt: now.Add(-2 * time.Hour)},
+		{SubstanceID: "iron", AmountMg: 25, IngestedAt: now.Add(-30 * time.Hour)}, // Outside the window
+	}
+
+	status, err := advisor.CheckLimits(history, "iron", 25, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.TakenMg != 25 || status.RemainingMg == nil || *status.RemainingMg != 20 {
+		t.Errorf("Expected 25mg taken / 20mg remaining, got %+v", status)
+	}
+	if !status.ExceedsDaily {
+		t.Errorf("Expected 25mg more to exceed the 45mg cap")
+	}
+
+	// No cap: no allowance to report, rather than "0mg left"
+	status, err = advisor.CheckLimits(history, "calcium", 500, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.RemainingMg != nil || status.Exceeded() {
+		t.Errorf("Expected no remaining allowance without a cap, got %+v", status)
+	}
+}
+
+func TestCheckTimingCutoffAndWrap(t *testing.T) {
+	repo := newStubRepo()
+	repo["coffee"] = domain.SubstanceDefinition{ID: "coffee", Name: "Coffee", Category: domain.CatStimulant, HalfLifeHours: 5}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	if err := advisor.SetTimeRules([]domain.TimeRule{{ID: "cutoff", Category: domain.CatStimulant, NotAfter: "16:00"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	profile := domain.UserProfile{
+		UserID:   "u",
+		Timezone: "UTC",
+		// Iron only between 22:00 and 06:00 (wraps midnight)
+		TimeRules: []domain.TimeRule{{ID: "night-iron", SubstanceID: "iron", NotBefore: "22:00", NotAfter: "06:00"}},
+	}
+
+	late := time.Date(2026, 1, 1, 17, 30, 0, 0, time.UTC)
+	violations, err := advisor.CheckTiming("coffee", late, profile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Message != "Stimulant proposed at 17:30, cutoff 16:00" {
+		t.Errorf("Expected a cutoff violation, got %+v", violations)
+	}
+
+	midnight := time.Date(2026, 1, 1, 0, 15, 0, 0, time.UTC)
+	if violations, _ := advisor.CheckTiming("iron", midnight, profile); len(violations) != 0 {
+		t.Errorf("Expected 00:15 to be inside the wrapped window, got %+v", violations)
+	}
+	if violations, _ := advisor.CheckTiming("iron", late, profile); len(violations) != 1 {
+		t.Errorf("Expected 17:30 to be outside the wrapped window, got %+v", violations)
+	}
+}
+
+func TestCheckBatchPairwise(t *testing.T) {
+	advisor := NewAdvisor(newStubRepo(), NewMetabolicCalculator())
+
+	base := time.Now()
+	batch := []ProposedDose{
+		{SubstanceID: "iron", AmountMg: 25, At: base.Add(30 * time.Minute)},
+		{SubstanceID: "calcium", AmountMg: 500, At: base},
+		{SubstanceID: "vitamin-c", AmountMg: 500, At: base.Add(5 * time.Hour)}, // Long after the 0.5h window
+	}
+
+	stack, pairs, err := advisor.CheckBatch(nil, batch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stack) != 3 {
+		t.Fatalf("Expected one stack result per item, got %d", len(stack))
+	}
+
+	// Calcium is taken first, so it plays the active role against Iron
+	if len(pairs) != 1 {
+		t.Fatalf("Expected 1 pairwise conflict, got %+v", pairs)
+	}
+	if pairs[0].Item != 0 || pairs[0].WithItem != 1 || pairs[0].Type != domain.TypeInhibit {
+		t.Errorf("Unexpected pair: %+v", pairs[0])
+	}
+}
+
+func TestPlanWashoutWithMetabolite(t *testing.T) {
+	repo := newStubRepo()
+	repo["maoi"] = domain.SubstanceDefinition{ID: "maoi", Name: "MAOI", HalfLifeHours: 2,
+		Metabolites: []domain.Metabolite{{SubstanceID: "maoi-met", Fraction: 0.5}}}
+	repo["maoi-met"] = domain.SubstanceDefinition{ID: "maoi-met", Name: "MAOI Metabolite", HalfLifeHours: 10}
+	repo["ssri"] = domain.SubstanceDefinition{ID: "ssri", Name: "SSRI", HalfLifeHours: 20, Interactions: []domain.Interaction{
+		{TargetID: "maoi-met", Type: domain.TypeDangerous, WindowHours: 24, Note: "Serotonin syndrome."},
+	}}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	now := time.Now()
+	stack := []domain.ActiveDose{{SubstanceID: "maoi", AmountMg: 100, IngestedAt: now}}
+
+	plan, err := advisor.PlanWashout(stack, "ssri", ClearanceCriterion{HalfLives: 5}, domain.UserProfile{}, time.Time{}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Entries) != 1 {
+		t.Fatalf("Expected the MAOI to need a washout via its metabolite, got %+v", plan)
+	}
+
+	// The slow metabolite (10h) dominates the fast parent (2h): well over 50h
+	if wait := plan.EarliestStart.Sub(now).Hours(); wait < 50 {
+		t.Errorf("Expected the metabolite to set a 50h+ washout, got %f", wait)
+	}
+
+	// A personal half-life for the parent is honored
+	fast := domain.UserProfile{HalfLifeOverrides: map[string]float64{"maoi": 1}}
+	personal, err := advisor.PlanWashout(stack, "ssri", ClearanceCriterion{HalfLives: 5}, fast, time.Time{}, now)
+	if err != nil || len(personal.Entries) == 0 || len(personal.Entries[0].Species) == 0 {
+		t.Fatalf("Expected a personal plan with species, got %+v (%v)", personal, err)
+	}
+	if personal.Entries[0].Species[0].HalfLifeHours != 1 {
+		t.Errorf("Expected the personal half-life to be used, got %+v", personal.Entries[0].Species[0])
+	}
+}
+
+func TestCheckContraindications(t *testing.T) {
+	repo := newStubRepo()
+	iron := repo["iron"]
+	iron.Contraindications = []domain.Contraindication{
+		{Condition: "hemochromatosis", Type: domain.TypeDangerous, Note: "Iron overload."},
+	}
+	repo["iron"] = iron
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	profile := domain.UserProfile{
+		Conditions:  []string{"Hemochromatosis"},
+		Medications: []string{"calcium"}, // A catalog substance taken long-term
+	}
+
+	conflicts, err := advisor.CheckContraindications("iron", profile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected condition + medication conflicts, got %+v", conflicts)
+	}
+	if conflicts[0].Direction != DirCondition || !conflicts[0].Severe() {
+		t.Errorf("Expected a severe condition conflict first, got %+v", conflicts[0])
+	}
+	if conflicts[1].Direction != DirMedication || conflicts[1].Type != domain.TypeInhibit {
+		t.Errorf("Expected the calcium rule via medications, got %+v", conflicts[1])
+	}
+}
+
+func TestCheckLoadCrossesThreshold(t *testing.T) {
+	repo := newStubRepo()
+	repo["coffee"] = domain.SubstanceDefinition{ID: "coffee", Name: "Coffee", HalfLifeHours: 5, Loads: map[string]float64{"stimulant": 1}}
+	repo["guarana"] = domain.SubstanceDefinition{ID: "guarana", Name: "Guarana", HalfLifeHours: 5, Loads: map[string]float64{"stimulant": 0.5}}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+	if err := advisor.SetLoadAxes([]domain.LoadAxis{{ID: "stimulant", Name: "Stimulant Load", Threshold: 300}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	stack := []domain.ActiveDose{{SubstanceID: "coffee", AmountMg: 200, IngestedAt: now}}
+
+	// 200 + 0.5 x 100 = 250: still fine
+	if warnings, _ := advisor.CheckLoad(stack, "guarana", 100, now); len(warnings) != 0 {
+		t.Errorf("Expected no warning at 250, got %+v", warnings)
+	}
+
+	// 200 + 0.5 x 300 = 350: crossed by this dose
+	warnings, err := advisor.CheckLoad(stack, "guarana", 300, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !warnings[0].CrossedByDose || warnings[0].AfterLoad != 350 {
+		t.Errorf("Expected the dose to cross the threshold at 350, got %+v", warnings)
+	}
+}
+
+func TestProjectPeakStacksDoses(t *testing.T) {
+	repo := newStubRepo()
+	repo["coffee"] = domain.SubstanceDefinition{ID: "coffee", Name: "Coffee", HalfLifeHours: 5, ToxicMg: 400}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	now := time.Now()
+	stack := []domain.ActiveDose{{SubstanceID: "coffee", AmountMg: 300, IngestedAt: now.Add(-5 * time.Hour)}}
+
+	// 150 left + 200 new = 350: under the toxic level
+	peak, err := advisor.ProjectPeak(stack, ProposedDose{SubstanceID: "coffee", AmountMg: 200, At: now})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak.Exceeds || peak.PeakMg < 349 || peak.PeakMg > 351 {
+		t.Errorf("Expected a ~350mg peak under the limit, got %+v", peak)
+	}
+
+	// 150 left + 300 new = 450: each dose alone is fine, the stack is not
+	peak, _ = advisor.ProjectPeak(stack, ProposedDose{SubstanceID: "coffee", AmountMg: 300, At: now})
+	if !peak.Exceeds || !peak.PeakAt.Equal(now) {
+		t.Errorf("Expected the stacked peak to exceed 400mg now, got %+v", peak)
+	}
+}
+
+func TestFilterByEvidence(t *testing.T) {
+	repo := newStubRepo()
+	lactoferrin := repo["lactoferrin"]
+	lactoferrin.Interactions[0].Evidence = domain.EvidenceTheoretical
+	repo["lactoferrin"] = lactoferrin
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	now := time.Now()
+	stack := []domain.ActiveDose{
+		{SubstanceID: "vitamin-c", AmountMg: 500, IngestedAt: now},   // Ungraded: counts as established
+		{SubstanceID: "lactoferrin", AmountMg: 200, IngestedAt: now}, // Theoretical
+	}
+	conflicts, err := advisor.CheckSafetyAt(stack, "iron", 0, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kept, suppressed := FilterByEvidence(conflicts, domain.EvidenceEstablished)
+	if len(kept) != 1 || kept[0].Evidence != domain.EvidenceEstablished {
+		t.Errorf("Expected only the established conflict, got %+v", kept)
+	}
+	if len(suppressed) != 1 || suppressed[0].SubstanceA != "Lactoferrin" {
+		t.Errorf("Expected lactoferrin to be suppressed, got %+v", suppressed)
+	}
+
+	// Including theoretical keeps both; no strictness keeps everything
+	for _, level := range []domain.EvidenceLevel{domain.EvidenceTheoretical, ""} {
+		if kept, _ := FilterByEvidence(conflicts, level); len(kept) != 2 {
+			t.Errorf("Expected both conflicts at strictness %q, got %d", level, len(kept))
+		}
+	}
+}
+
+func TestToActiveConvertsSaltForms(t *testing.T) {
+	repo := newStubRepo()
+	repo["calcium-carbonate"] = domain.SubstanceDefinition{ID: "calcium-carbonate", Name: "Calcium Carbonate", ActiveMoietyID: "calcium", ActiveFraction: 0.4}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	now := time.Now()
+	dose, err := advisor.ToActive(domain.ActiveDose{SubstanceID: "calcium-carbonate", AmountMg: 1000, IngestedAt: now})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dose.SubstanceID != "calcium" || math.Abs(dose.AmountMg-400) > 1e-9 {
+		t.Errorf("Expected 400mg calcium, got %gmg %s", dose.AmountMg, dose.SubstanceID)
+	}
+	if dose.LabelledID != "calcium-carbonate" || dose.LabelledMg != 1000 {
+		t.Errorf("Expected the label to be kept, got %s %gmg", dose.LabelledID, dose.LabelledMg)
+	}
+
+	// The moiety's rules apply: 400mg calcium is enough to hit iron
+	conflicts, err := advisor.CheckSafetyAt([]domain.ActiveDose{dose}, "iron", 0, now)
+	if err != nil || len(conflicts) != 1 {
+		t.Errorf("Expected calcium's iron rule to fire, got %v (%v)", conflicts, err)
+	}
+
+	// Plain substances pass through untouched
+	if id, mg, _ := advisor.ActiveAmount("iron", 25); id != "iron" || mg != 25 {
+		t.Errorf("Expected iron to pass through, got %s %g", id, mg)
+	}
+}
+
+func TestToMgAndDisplay(t *testing.T) {
+	repo := newStubRepo()
+	repo["vitamin-d3"] = domain.SubstanceDefinition{ID: "vitamin-d3", Name: "Vitamin D3",
+		Conversions: []domain.UnitConversion{{From: domain.UnitIU, To: domain.UnitMcg, Factor: 0.025}}}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	// Mass units convert for any substance; IU needs the substance's rule
+	if mg, err := advisor.ToMg("iron", domain.Amount{Value: 0.5, Unit: domain.UnitG}); err != nil || mg != 500 {
+		t.Errorf("Expected 0.5 g = 500mg, got %g (%v)", mg, err)
+	}
+	if mg, err := advisor.ToMg("vitamin-d3", domain.Amount{Value: 1000, Unit: domain.UnitIU}); err != nil || math.Abs(mg-0.025) > 1e-12 {
+		t.Errorf("Expected 1000 IU = 0.025mg, got %g (%v)", mg, err)
+	}
+	if _, err := advisor.ToMg("iron", domain.Amount{Value: 1000, Unit: domain.UnitIU}); err == nil {
+		t.Error("Expected IU of a substance without a conversion to fail")
+	}
+
+	profile := domain.UserProfile{PreferredUnits: map[string]domain.Unit{"vitamin-d3": domain.UnitIU}}
+	if got := advisor.Display("vitamin-d3", 0.05, profile); got == nil || math.Abs(got.Value-2000) > 1e-9 || got.Unit != domain.UnitIU {
+		t.Errorf("Expected 2000 IU, got %v", got)
+	}
+	if got := advisor.Display("iron", 25, profile); got != nil {
+		t.Errorf("Expected no display without a preference, got %v", got)
+	}
+}
+
+func TestBuildTraceExplainsTheArithmetic(t *testing.T) {
+	rule := domain.Interaction{TargetID: "iron", Type: domain.TypeInhibit, WindowHours: 2, MinSourceMg: 300, MinTargetMg: 10, WindowScaleMg: 500, MaxWindowHours: 4}
+	taken := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	// 1. Active calcium owns the rule: thresholds met, window scaled then capped
+	dose := domain.ActiveDose{ID: "d1", SubstanceID: "calcium", AmountMg: 1500, IngestedAt: taken}
+	trace := buildTrace(DirActiveToProposed, "calcium", "iron", rule, dose, 1200, 25, 1500, time.Hour, ruleWindow(rule, 1500))
+	want := []string{
+		"Owner dose 1500mg >= threshold 300mg",
+		"Target dose 25mg >= threshold 10mg",
+		"Window = 2.0h x (1500mg / 500mg) = 6.0h",
+		"Window capped at 4.0h",
+		"Elapsed 1.0h < window 4.0h -> wait 3h0m0s",
+	}
+	if !slices.Equal(trace.Steps, want) {
+		t.Errorf("Unexpected steps:\n got %q\nwant %q", trace.Steps, want)
+	}
+	if trace.WindowHours != 4 || trace.BaseWindowHours != 2 || trace.TriggerDoseID != "d1" || trace.RemainingMg != 1200 {
+		t.Errorf("Unexpected trace fields %+v", trace)
+	}
+	lines := trace.Explain()
+	if lines[0] != "Rule: calcium -> iron (active->proposed)" || lines[1] != "Trigger: 1500mg taken 8:00AM, 1200.0mg remaining" || len(lines) != 2+len(want) {
+		t.Errorf("Unexpected explanation %q", lines)
+	}
+
+	// 2. The proposed side owns the rule with an unknown dose: no threshold claims, fixed window
+	dose = domain.ActiveDose{ID: "d2", SubstanceID: "iron", AmountMg: 25, IngestedAt: taken}
+	trace = buildTrace(DirProposedToActive, "calcium", "iron", rule, dose, 20, 0, 0, 30*time.Minute, ruleWindow(rule, 0))
+	want = []string{
+		"Owner dose unknown, threshold 300mg not applied",
+		"Target dose 25mg >= threshold 10mg",
+		"Window = 2.0h (fixed)",
+		"Elapsed 0.5h < window 2.0h -> wait 1h30m0s",
+	}
+	if !slices.Equal(trace.Steps, want) {
+		t.Errorf("Unexpected steps:\n got %q\nwant %q", trace.Steps, want)
+	}
+	if trace.Direction != DirProposedToActive || !slices.Equal(trace.ResolutionPath, []string{"calcium", "iron"}) {
+		t.Errorf("Unexpected direction or path %+v", trace)
+	}
+}
+
+func TestCheckSafetyResolvesClassRules(t *testing.T) {
+	repo := newStubRepo()
+	repo["sertraline"] = domain.SubstanceDefinition{ID: "sertraline", Name: "Sertraline", HalfLifeHours: 26, Classes: []string{"SSRI"}}
+	repo["dxm"] = domain.SubstanceDefinition{ID: "dxm", Name: "DXM", HalfLifeHours: 4, Interactions: []domain.Interaction{
+		{TargetClass: "ssri", Type: domain.TypeDangerous, WindowHours: 24, Note: "Serotonin syndrome."},
+	}}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	stack := []domain.ActiveDose{{ID: "d1", SubstanceID: "sertraline", AmountMg: 50, IngestedAt: time.Now().Add(-time.Hour)}}
+	conflicts, err := advisor.CheckSafety(stack, "dxm", 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Type != domain.TypeDangerous {
+		t.Fatalf("Expected the class rule to fire against sertraline, got %+v", conflicts)
+	}
+	trace := conflicts[0].Trace
+	if trace.RuleTarget != "class:ssri" || !slices.Equal(trace.ResolutionPath, []string{"dxm", "class:ssri", "sertraline"}) {
+		t.Errorf("Expected the path through the class, got %+v", trace)
+	}
+	if line := trace.Explain()[0]; line != "Rule: dxm -> class:ssri -> sertraline (proposed->active)" {
+		t.Errorf("Unexpected explanation %q", line)
+	}
+
+	// Iron isn't an SSRI
+	iron := []domain.ActiveDose{{SubstanceID: "iron", AmountMg: 25, IngestedAt: time.Now()}}
+	if conflicts, _ := advisor.CheckSafety(iron, "dxm", 30); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts outside the class, got %+v", conflicts)
+	}
+}
+
+func TestBuildMatrixPrefersTheRowsOwnRule(t *testing.T) {
+	repo := newStubRepo()
+	iron := repo["iron"]
+	iron.Interactions = []domain.Interaction{{TargetID: "calcium", Type: domain.TypeInhibit, WindowHours: 3, Note: "Iron's own rule."}}
+	repo["iron"] = iron
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	m, err := advisor.BuildMatrix([]string{"iron", "calcium", "vitamin-c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(m.Names, []string{"Iron", "Calcium", "Vitamin C"}) || len(m.Cells) != 3 {
+		t.Fatalf("Unexpected matrix shape %+v", m)
+	}
+
+	cases := []struct {
+		row, col int
+		want     MatrixCell
+	}{
+		{0, 1, MatrixCell{Type: domain.TypeInhibit, WindowHours: 3, Note: "Iron's own rule.", Source: "direct"}}, // Both have a rule: the row's wins
+		{1, 0, MatrixCell{Type: domain.TypeInhibit, WindowHours: 2, Note: "Competes for DMT1.", Source: "direct"}},
+		{0, 2, MatrixCell{Type: domain.TypePotentiate, WindowHours: 0.5, Note: "Reduces ferric iron.", Source: "reverse"}}, // Only the column has one
+		{1, 2, MatrixCell{}},
+		{0, 0, MatrixCell{}},
+	}
+	for _, c := range cases {
+		if got := m.Cells[c.row][c.col]; got != c.want {
+			t.Errorf("Cells[%d][%d] = %+v, want %+v", c.row, c.col, got, c.want)
+		}
+	}
+	if label := m.Cells[0][2].Label(); label != "POTENTIATE 0.5h (reverse)" {
+		t.Errorf("Unexpected label %q", label)
+	}
+
+	if _, err := advisor.BuildMatrix([]string{"iron", "unobtainium"}); err == nil {
+		t.Error("Expected an unknown substance to fail")
+	}
+}
+
+func TestBuildMatrixResolvesClassRules(t *testing.T) {
+	repo := newStubRepo()
+	repo["sertraline"] = domain.SubstanceDefinition{ID: "sertraline", Name: "Sertraline", HalfLifeHours: 26, Classes: []string{"ssri"}}
+	repo["dxm"] = domain.SubstanceDefinition{ID: "dxm", Name: "DXM", HalfLifeHours: 4, Interactions: []domain.Interaction{
+		{TargetClass: "ssri", Type: domain.TypeDangerous, WindowHours: 24, Note: "Serotonin syndrome."},
+	}}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+
+	m, err := advisor.BuildMatrix([]string{"dxm", "sertraline", "iron"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	direct := MatrixCell{Type: domain.TypeDangerous, WindowHours: 24, Note: "Serotonin syndrome.", Source: "direct", Class: "ssri"}
+	if got := m.Cells[0][1]; got != direct {
+		t.Errorf("Cells[0][1] = %+v, want %+v", got, direct)
+	}
+	if got := m.Cells[1][0]; got.Source != "reverse" || got.Class != "ssri" || got.Label() != "DANGEROUS 24h via ssri (reverse)" {
+		t.Errorf("Unexpected reverse cell %+v (%q)", got, got.Label())
+	}
+	if got := m.Cells[0][2]; got != (MatrixCell{}) {
+		t.Errorf("Expected iron to be outside the class, got %+v", got)
+	}
+}
+
+func TestPlanWashoutReportsTiming(t *testing.T) {
+	repo := newStubRepo()
+	repo["coffee"] = domain.SubstanceDefinition{ID: "coffee", Name: "Coffee", Category: domain.CatStimulant, HalfLifeHours: 5}
+	advisor := NewAdvisor(repo, NewMetabolicCalculator())
+	if err := advisor.SetTimeRules([]domain.TimeRule{{ID: "cutoff", Category: domain.CatStimulant, NotAfter: "16:00"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	profile := domain.UserProfile{Timezone: "UTC"}
+
+	// Nothing to wash out, but 18:00 is past the stimulant cutoff
+	evening := time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC)
+	plan, err := advisor.PlanWashout(nil, "coffee", DefaultCriterion, profile, time.Time{}, evening)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Timing) != 1 || plan.Timing[0].RuleID != "cutoff" {
+		t.Errorf("Expected the cutoff to be reported, got %+v", plan.Timing)
+	}
+
+	// A morning start is fine
+	morning := time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)
+	if plan, _ := advisor.PlanWashout(nil, "coffee", DefaultCriterion, profile, morning, evening); len(plan.Timing) != 0 {
+		t.Errorf("Expected no timing violations at 08:00, got %+v", plan.Timing)
+	}
+}
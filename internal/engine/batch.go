@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ProposedDose is one item of a stack the user is about to take.
+type ProposedDose struct {
+	SubstanceID string
+	AmountMg    float64
+	At          time.Time // When this item will be taken
+}
+
+// PairConflict is a conflict between two items of the same proposed batch.
+type PairConflict struct {
+	Item     int `json:"item"`      // The later item (treated as the proposed side)
+	WithItem int `json:"with_item"` // The earlier item (treated as already active)
+	Conflict
+}
+
+// CheckBatch checks every proposed item against the active stack and against
+// each other. For a pair, the item taken first plays the "active" role; ties are
+// broken by list order, so every unordered pair is evaluated exactly once.
+func (a *Advisor) CheckBatch(activeStack []domain.ActiveDose, proposed []ProposedDose) (stack [][]Conflict, pairs []PairConflict, err error) {
+	stack = make([][]Conflict, len(proposed))
+
+	for j, item := range proposed {
+		// 1. Against what is already in the bloodstream
+		stack[j], err = a.CheckSafetyAt(activeStack, item.SubstanceID, item.AmountMg, item.At)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// 2. Against the items taken before it in this batch
+		for i, earlier := range proposed {
+			if i == j || !takenBefore(earlier, i, item, j) {
+				continue
+			}
+			asActive := []domain.ActiveDose{{
+				SubstanceID: earlier.SubstanceID,
+				AmountMg:    earlier.AmountMg,
+				IngestedAt:  earlier.At,
+			}}
+			found, err := a.CheckSafetyAt(asActive, item.SubstanceID, item.AmountMg, item.At)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, c := range found {
+				pairs = append(pairs, PairConflict{Item: j, WithItem: i, Conflict: c})
+			}
+		}
+	}
+
+	return stack, pairs, nil
+}
+
+// takenBefore orders batch items by time, then by position.
+func takenBefore(a ProposedDose, ai int, b ProposedDose, bi int) bool {
+	if a.At.Equal(b.At) {
+		return ai < bi
+	}
+	return a.At.Before(b.At)
+}
@@ -56,3 +56,43 @@ func (c *MetabolicCalculator) TimeUntilClearance(currentMg float64, targetMg flo
 
 	return time.Duration(hoursNeeded * float64(time.Hour))
 }
+
+// LatestIntakeLead inverts the decay curve for a new dose.
+// Given what will already be left at the target time ('existingMg'), it returns how long
+// before the target a 'doseMg' dose must be taken so the total stays at or under 'thresholdMg'.
+// ok is false when the existing load alone already breaks the threshold.
+func (c *MetabolicCalculator) LatestIntakeLead(existingMg float64, doseMg float64, thresholdMg float64, halfLifeHours float64) (lead time.Duration, ok bool) {
+	headroom := thresholdMg - existingMg
+	if headroom <= 0 {
+		return 0, false
+	}
+	if doseMg <= headroom {
+		return 0, true
+	}
+
+	// doseMg * e^(-k*t) = headroom  =>  t = ln(doseMg / headroom) / k
+	k := math.Log(2) / halfLifeHours
+	hoursNeeded := math.Log(doseMg/headroom) / k
+
+	return time.Duration(hoursNeeded * float64(time.Hour)), true
+}
+
+// MetaboliteAmount models a metabolite formed from a parent dose (Bateman function).
+// fraction: share of the parent converted into the metabolite
+// Formula: M(t) = f * D * kp / (km - kp) * (e^(-kp*t) - e^(-km*t))
+func (c *MetabolicCalculator) MetaboliteAmount(parentMg float64, fraction float64, parentHalfLife float64, metaboliteHalfLife float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	kp := math.Log(2) / parentHalfLife
+	km := math.Log(2) / metaboliteHalfLife
+	t := elapsed.Hours()
+
+	// Equal rate constants: take the limit of the formula to avoid dividing by zero
+	if math.Abs(km-kp) < 1e-9 {
+		return fraction * parentMg * kp * t * math.Exp(-kp*t)
+	}
+
+	return fraction * parentMg * kp / (km - kp) * (math.Exp(-kp*t) - math.Exp(-km*t))
+}
@@ -3,6 +3,8 @@ package engine
 import (
 	"testing"
 	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
 )
 
 func TestRemainingAmount(t *testing.T) {
@@ -37,3 +39,48 @@ func TestTimeUntilClearance(t *testing.T) {
 		t.Errorf("Expected ~4 hours, got %f", wait.Hours())
 	}
 }
+
+func TestLatestIntakeLead(t *testing.T) {
+	calc := NewMetabolicCalculator()
+
+	// Scenario: 100mg dose, 50mg threshold, nothing else active, 5h half-life.
+	// One half-life is needed, so the dose must be taken 5h before bedtime.
+	lead, ok := calc.LatestIntakeLead(0, 100, 50, 5)
+	if !ok || lead.Hours() < 4.9 || lead.Hours() > 5.1 {
+		t.Errorf("Expected ~5h lead, got %f (ok=%v)", lead.Hours(), ok)
+	}
+
+	// With 25mg already left at bedtime, the new dose may only contribute 25mg: two half-lives.
+	lead, ok = calc.LatestIntakeLead(25, 100, 50, 5)
+	if !ok || lead.Hours() < 9.9 || lead.Hours() > 10.1 {
+		t.Errorf("Expected ~10h lead, got %f (ok=%v)", lead.Hours(), ok)
+	}
+
+	// Existing load already over the threshold: impossible
+	if _, ok := calc.LatestIntakeLead(60, 100, 50, 5); ok {
+		t.Errorf("Expected no possible intake time")
+	}
+}
+
+func TestClassifyWindow(t *testing.T) {
+	calc := NewMetabolicCalculator()
+	def := domain.SubstanceDefinition{HalfLifeHours: 5, MinEffectiveMg: 50, ToxicMg: 400}
+
+	// 800mg is excessive; one half-life (5h) until it drops to 400mg
+	status := calc.ClassifyWindow(def, 800)
+	if status.Label != LabelExcessive || status.NextLabel != LabelTherapeutic {
+		t.Errorf("Expected excessive -> therapeutic, got %+v", status)
+	}
+	if status.ChangesIn.Hours() < 4.9 || status.ChangesIn.Hours() > 5.1 {
+		t.Errorf("Expected ~5h until therapeutic, got %f", status.ChangesIn.Hours())
+	}
+
+	// 100mg is therapeutic; one half-life until subtherapeutic
+	if status := calc.ClassifyWindow(def, 100); status.Label != LabelTherapeutic || status.ChangesIn.Hours() < 4.9 {
+		t.Errorf("Expected therapeutic for ~5h, got %+v", status)
+	}
+
+	if status := calc.ClassifyWindow(def, 10); status.Label != LabelSubtherapeutic || status.ChangesIn != 0 {
+		t.Errorf("Expected a final subtherapeutic label, got %+v", status)
+	}
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// TimeViolation reports a dose proposed outside its allowed time-of-day window.
+type TimeViolation struct {
+	RuleID      string `json:"rule_id"`
+	Scope       string `json:"scope"` // "catalog" or "user"
+	SubstanceID string `json:"substance_id"`
+	LocalTime   string `json:"local_time"` // Proposed time on the user's clock (HH:MM)
+	Message     string `json:"message"`
+	Note        string `json:"note,omitempty"`
+}
+
+// SetTimeRules installs the catalog's time-of-day rules after validating them.
+func (a *Advisor) SetTimeRules(rules []domain.TimeRule) error {
+	for _, rule := range rules {
+		if err := ValidateTimeRule(rule); err != nil {
+			return err
+		}
+	}
+	a.timeRules = rules
+	return nil
+}
+
+// ValidateTimeRule checks that a rule has a subject and well-formed clock times.
+func ValidateTimeRule(rule domain.TimeRule) error {
+	if rule.Category == "" && rule.SubstanceID == "" {
+		return fmt.Errorf("time rule %q needs a category or substance_id", rule.ID)
+	}
+	if rule.NotBefore == "" && rule.NotAfter == "" {
+		return fmt.Errorf("time rule %q needs not_before and/or not_after", rule.ID)
+	}
+	for _, clock := range []string{rule.NotBefore, rule.NotAfter} {
+		if clock == "" {
+			continue
+		}
+		if _, err := parseClock(clock); err != nil {
+			return fmt.Errorf("time rule %q: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// CheckTiming evaluates the catalog rules and the user's own rules for a dose at 'at'.
+// Times are compared on the wall clock of the profile's timezone.
+func (a *Advisor) CheckTiming(substanceID string, at time.Time, profile domain.UserProfile) ([]TimeViolation, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+
+	loc, err := UserLocation(profile)
+	if err != nil {
+		return nil, err
+	}
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	var violations []TimeViolation
+	check := func(scope string, rules []domain.TimeRule) {
+		for _, rule := range rules {
+			if !ruleCovers(rule, def) {
+				continue
+			}
+			if msg, broken := clockViolation(rule, def, local, minute); broken {
+				violations = append(violations, TimeViolation{
+					RuleID:      rule.ID,
+					Scope:       scope,
+					SubstanceID: def.ID,
+					LocalTime:   local.Format("15:04"),
+					Message:     msg,
+					Note:        rule.Note,
+				})
+			}
+		}
+	}
+	check("catalog", a.timeRules)
+	check("user", profile.TimeRules)
+
+	return violations, nil
+}
+
+// UserLocation resolves the profile's timezone (server local time if unset).
+func UserLocation(profile domain.UserProfile) (*time.Location, error) {
+	if profile.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(profile.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", profile.Timezone, err)
+	}
+	return loc, nil
+}
+
+// NextClockTime returns the next occurrence of "HH:MM" at or after 'from', in 'loc'.
+func NextClockTime(clock string, from time.Time, loc *time.Location) (time.Time, error) {
+	minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := from.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	if next.Before(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// ruleCovers reports whether the rule targets this substance (directly or by category).
+func ruleCovers(rule domain.TimeRule, def domain.SubstanceDefinition) bool {
+	if rule.SubstanceID != "" {
+		return rule.SubstanceID == def.ID
+	}
+	return rule.Category == def.Category
+}
+
+// clockViolation checks 'minute' (minutes since local midnight) against the rule's window.
+func clockViolation(rule domain.TimeRule, def domain.SubstanceDefinition, local time.Time, minute int) (string, bool) {
+	subject := def.Name
+	if rule.SubstanceID == "" {
+		subject = string(rule.Category)
+	}
+
+	// Rules are validated on the way in, so parse errors can't happen here
+	start, end := 0, 24*60
+	if rule.NotBefore != "" {
+		start, _ = parseClock(rule.NotBefore)
+	}
+	if rule.NotAfter != "" {
+		end, _ = parseClock(rule.NotAfter)
+	}
+
+	allowed := minute >= start && minute < end
+	if start > end {
+		// Window wraps past midnight (e.g., 22:00 -> 06:00)
+		allowed = minute >= start || minute < end
+	}
+	if allowed {
+		return "", false
+	}
+
+	if rule.NotAfter != "" && (rule.NotBefore == "" || minute >= end) {
+		return fmt.Sprintf("%s proposed at %s, cutoff %s", subject, local.Format("15:04"), rule.NotAfter), true
+	}
+	return fmt.Sprintf("%s proposed at %s, not before %s", subject, local.Format("15:04"), rule.NotBefore), true
+}
+
+// parseClock turns "HH:MM" into minutes since midnight.
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time %q (use HH:MM)", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+const (
+	// DirCondition: the substance is contraindicated with one of the user's health conditions.
+	DirCondition Direction = "condition"
+
+	// DirMedication: the substance clashes with one of the user's long-term medications.
+	DirMedication Direction = "medication"
+)
+
+// CheckContraindications matches the substance against the profile's conditions and medications.
+// Long-term medications that are catalog substances are also run through the interaction
+// graph; since they are taken continuously, their windows never close (WaitTime is zero).
+func (a *Advisor) CheckContraindications(substanceID string, profile domain.UserProfile) ([]Conflict, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+
+	var conflicts []Conflict
+	add := func(dir Direction, subject string, kind string, typ domain.InteractionType, note string, evidence domain.EvidenceLevel, path []string) {
+		conflicts = append(conflicts, Conflict{
+			SubstanceA: subject,
+			SubstanceB: def.Name,
+			Type:       typ,
+			Reason:     note,
+			Direction:  dir,
+			Evidence:   evidenceOf(evidence),
+			Trace: &ConflictTrace{
+				Direction:      dir,
+				RuleOwner:      path[0],
+				RuleTarget:     path[len(path)-1],
+				ResolutionPath: path,
+				Steps:          []string{fmt.Sprintf("Profile lists %s %q", kind, subject)},
+			},
+		})
+	}
+
+	// 1. Catalog contraindications vs the profile
+	for _, ci := range def.Contraindications {
+		for _, cond := range profile.Conditions {
+			if ci.Condition != "" && sameTerm(ci.Condition, cond) {
+				add(DirCondition, cond, "condition", ci.Type, ci.Note, ci.Evidence, []string{def.ID, ci.Condition})
+			}
+		}
+		for _, med := range profile.Medications {
+			if ci.Medication != "" && sameTerm(ci.Medication, med) {
+				add(DirMedication, med, "medication", ci.Type, ci.Note, ci.Evidence, []string{def.ID, ci.Medication})
+			}
+		}
+	}
+
+	// 2. Medications that are themselves catalog substances: use the graph, both ways
+	for _, med := range profile.Medications {
+		medDef, err := a.repo.GetDefinition(med)
+		if err != nil || medDef.ID == def.ID {
+			continue
+		}
+		if rule, found := a.findInteraction(medDef, def); found {
+			add(DirMedication, medDef.Name, "medication", rule.Type, rule.Note, rule.Evidence, resolutionPath(medDef.ID, rule, def.ID))
+		}
+		if rule, found := a.findInteraction(def, medDef); found {
+			add(DirMedication, medDef.Name, "medication", rule.Type, rule.Note, rule.Evidence, resolutionPath(def.ID, rule, medDef.ID))
+		}
+	}
+
+	return conflicts, nil
+}
+
+// sameTerm compares free-text health terms loosely ("Anticoagulants" == "anticoagulants ").
+func sameTerm(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
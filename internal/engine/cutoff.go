@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Cutoff answers "what's the latest I can take X and still be under Y at bedtime?".
+type Cutoff struct {
+	SubstanceID  string    `json:"substance_id"`
+	DoseMg       float64   `json:"dose_mg"`
+	ThresholdMg  float64   `json:"threshold_mg"`
+	Bedtime      time.Time `json:"bedtime"`
+	ExistingMg   float64   `json:"existing_at_bedtime_mg"` // Left over from the current stack at bedtime
+	Possible     bool      `json:"possible"`               // False if the stack alone breaks the threshold
+	LatestIntake time.Time `json:"latest_intake,omitzero"`
+	TooLate      bool      `json:"too_late"` // The latest intake time has already passed
+	Message      string    `json:"message"`
+}
+
+// LatestIntake computes the last moment a dose can be taken so that the summed
+// load of that substance (stack + new dose) is at or under 'thresholdMg' at 'bedtime'.
+func (a *Advisor) LatestIntake(stack []domain.ActiveDose, substanceID string, doseMg, thresholdMg float64, bedtime, now time.Time) (Cutoff, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return Cutoff{}, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+	if thresholdMg <= 0 {
+		return Cutoff{}, fmt.Errorf("threshold must be > 0")
+	}
+
+	result := Cutoff{
+		SubstanceID: def.ID,
+		DoseMg:      doseMg,
+		ThresholdMg: thresholdMg,
+		Bedtime:     bedtime,
+	}
+
+	// 1. What will be left of the current stack at bedtime?
+	for _, dose := range stack {
+		if dose.SubstanceID == def.ID {
+			result.ExistingMg += a.calc.RemainingAmount(dose.AmountMg, def.HalfLifeHours, bedtime.Sub(dose.IngestedAt))
+		}
+	}
+
+	// 2. Invert the decay curve for the new dose
+	lead, ok := a.calc.LatestIntakeLead(result.ExistingMg, doseMg, thresholdMg, def.HalfLifeHours)
+	if !ok {
+		result.Message = fmt.Sprintf("%.1fmg of %s will still be active at %s, already over the %.0fmg threshold",
+			result.ExistingMg, def.Name, bedtime.Format("15:04"), thresholdMg)
+		return result, nil
+	}
+
+	result.Possible = true
+	result.LatestIntake = bedtime.Add(-lead)
+	result.TooLate = result.LatestIntake.Before(now)
+
+	if result.TooLate {
+		result.Message = fmt.Sprintf("Too late: %.0fmg %s had to be taken by %s to be under %.0fmg at %s",
+			doseMg, def.Name, result.LatestIntake.Format("15:04"), thresholdMg, bedtime.Format("15:04"))
+	} else {
+		result.Message = fmt.Sprintf("Take %.0fmg %s by %s to be under %.0fmg at %s",
+			doseMg, def.Name, result.LatestIntake.Format("15:04"), thresholdMg, bedtime.Format("15:04"))
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// evidenceOf fills in the default: an ungraded rule counts as established.
+func evidenceOf(level domain.EvidenceLevel) domain.EvidenceLevel {
+	if level == "" {
+		return domain.EvidenceEstablished
+	}
+	return level
+}
+
+// ValidateStrictness rejects unknown levels. Empty (report everything) is valid.
+func ValidateStrictness(level domain.EvidenceLevel) error {
+	if level != "" && !slices.Contains(domain.KnownEvidenceLevels, level) {
+		return fmt.Errorf("unknown evidence level %q (want one of %v)", level, domain.KnownEvidenceLevels)
+	}
+	return nil
+}
+
+// MeetsStrictness reports whether a conflict is at least as well supported as
+// 'weakest'. An empty 'weakest' admits everything.
+func MeetsStrictness(c Conflict, weakest domain.EvidenceLevel) bool {
+	if weakest == "" {
+		return true
+	}
+	return slices.Index(domain.KnownEvidenceLevels, evidenceOf(c.Evidence)) <= slices.Index(domain.KnownEvidenceLevels, weakest)
+}
+
+// FilterByEvidence splits conflicts into those the user asked to see and those
+// the strictness setting hides. Suppressed conflicts are still returned, so
+// callers can say what was left out.
+func FilterByEvidence(conflicts []Conflict, weakest domain.EvidenceLevel) (kept, suppressed []Conflict) {
+	for _, c := range conflicts {
+		if MeetsStrictness(c, weakest) {
+			kept = append(kept, c)
+		} else {
+			suppressed = append(suppressed, c)
+		}
+	}
+	return kept, suppressed
+}
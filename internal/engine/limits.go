@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// LimitWindow is the rolling period used for daily intake caps.
+const LimitWindow = 24 * time.Hour
+
+// LimitStatus reports how a proposed dose sits against the catalog's intake caps.
+type LimitStatus struct {
+	SubstanceID     string   `json:"substance_id"`
+	ProposedMg      float64  `json:"proposed_mg"`
+	TakenMg         float64  `json:"taken_24h_mg"`                 // Already taken in the rolling window
+	MaxDailyMg      float64  `json:"max_daily_mg,omitempty"`       // 0 = no daily cap
+	RemainingMg     *float64 `json:"remaining_mg,omitempty"`       // Allowance left before the proposed dose; nil = no daily cap
+	MaxSingleDoseMg float64  `json:"max_single_dose_mg,omitempty"` // 0 = no single-dose cap
+	ExceedsDaily    bool     `json:"exceeds_daily"`
+	ExceedsSingle   bool     `json:"exceeds_single"`
+	Message         string   `json:"message,omitempty"`
+}
+
+// Exceeded is true if the proposed dose breaks either cap.
+func (s LimitStatus) Exceeded() bool {
+	return s.ExceedsDaily || s.ExceedsSingle
+}
+
+// CheckLimits sums the user's intake of 'substanceID' over the 24h before 'at'
+// and checks whether adding 'proposedMg' would break the daily or single-dose cap.
+func (a *Advisor) CheckLimits(history []domain.ActiveDose, substanceID string, proposedMg float64, at time.Time) (LimitStatus, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return LimitStatus{}, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+
+	status := LimitStatus{
+		SubstanceID:     def.ID,
+		ProposedMg:      proposedMg,
+		MaxDailyMg:      def.MaxDailyMg,
+		MaxSingleDoseMg: def.MaxSingleDoseMg,
+	}
+
+	// 1. Rolling total: everything taken in (at - 24h, at]
+	since := at.Add(-LimitWindow)
+	for _, dose := range history {
+		if dose.SubstanceID != def.ID {
+			continue
+		}
+		if dose.IngestedAt.After(since) && !dose.IngestedAt.After(at) {
+			status.TakenMg += dose.AmountMg
+		}
+	}
+
+	// 2. Compare against the caps
+	if def.MaxDailyMg > 0 {
+		remaining := max(def.MaxDailyMg-status.TakenMg, 0)
+		status.RemainingMg = &remaining
+		if proposedMg > remaining {
+			status.ExceedsDaily = true
+			status.Message = fmt.Sprintf("%s: %.0fmg taken in the last 24h, only %.0fmg of the %.0fmg daily limit remains",
+				def.Name, status.TakenMg, remaining, def.MaxDailyMg)
+		}
+	}
+	if def.MaxSingleDoseMg > 0 && proposedMg > def.MaxSingleDoseMg {
+		status.ExceedsSingle = true
+		status.Message = fmt.Sprintf("%s: %.0fmg exceeds the %.0fmg single-dose limit",
+			def.Name, proposedMg, def.MaxSingleDoseMg)
+	}
+
+	return status, nil
+}
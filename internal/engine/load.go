@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+	"github.com/sitanshunandan/glate/internal/repository"
+)
+
+// LoadWarning reports a load axis over its catalog threshold.
+type LoadWarning struct {
+	AxisID        string  `json:"axis_id"`
+	Name          string  `json:"name"`
+	Threshold     float64 `json:"threshold"`
+	BeforeLoad    float64 `json:"before_load"` // Without the proposed dose
+	AfterLoad     float64 `json:"after_load"`  // With the proposed dose (equal to BeforeLoad in the monitor)
+	CrossedByDose bool    `json:"crossed_by_dose"`
+	Message       string  `json:"message"`
+}
+
+// SetLoadAxes installs the catalog's load axis thresholds.
+func (a *Advisor) SetLoadAxes(axes []domain.LoadAxis) error {
+	for _, axis := range axes {
+		if axis.ID == "" || axis.Threshold <= 0 {
+			return fmt.Errorf("load axis %q needs an id and a threshold > 0", axis.ID)
+		}
+	}
+	a.loadAxes = axes
+	return nil
+}
+
+// LoadAxes returns the installed axes (shared with the monitor).
+func (a *Advisor) LoadAxes() []domain.LoadAxis {
+	return a.loadAxes
+}
+
+// CheckLoad adds 'amountMg' of 'substanceID' at 'at' to the stack's weighted load
+// and warns for every axis that ends up over its threshold.
+func (a *Advisor) CheckLoad(stack []domain.ActiveDose, substanceID string, amountMg float64, at time.Time) ([]LoadWarning, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+
+	before := ComputeLoad(a.repo, a.calc, stack, at)
+	after := make(map[string]float64, len(before))
+	for axis, load := range before {
+		after[axis] = load
+	}
+	for axis, weight := range def.Loads {
+		after[axis] += amountMg * weight
+	}
+
+	var warnings []LoadWarning
+	for _, axis := range a.loadAxes {
+		if after[axis.ID] <= axis.Threshold {
+			continue
+		}
+		w := LoadWarning{
+			AxisID:        axis.ID,
+			Name:          axis.Name,
+			Threshold:     axis.Threshold,
+			BeforeLoad:    before[axis.ID],
+			AfterLoad:     after[axis.ID],
+			CrossedByDose: before[axis.ID] <= axis.Threshold,
+		}
+		if w.CrossedByDose {
+			w.Message = fmt.Sprintf("%s: %.0fmg %s takes the load from %.0f to %.0f (threshold %.0f)",
+				axis.Name, amountMg, def.Name, w.BeforeLoad, w.AfterLoad, axis.Threshold)
+		} else {
+			w.Message = fmt.Sprintf("%s is already %.0f (threshold %.0f); %s adds to it",
+				axis.Name, w.BeforeLoad, axis.Threshold, def.Name)
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings, nil
+}
+
+// ComputeLoad sums remaining mg x weight over the stack for every axis at time 'at'.
+// Unknown substances are skipped, as in the status endpoint.
+func ComputeLoad(repo repository.Repository, calc *MetabolicCalculator, stack []domain.ActiveDose, at time.Time) map[string]float64 {
+	loads := make(map[string]float64)
+	for _, dose := range stack {
+		def, err := repo.GetDefinition(dose.SubstanceID)
+		if err != nil || len(def.Loads) == 0 {
+			continue
+		}
+		remaining := calc.RemainingAmount(dose.AmountMg, def.HalfLifeHours, at.Sub(dose.IngestedAt))
+		for axis, weight := range def.Loads {
+			loads[axis] += remaining * weight
+		}
+	}
+	return loads
+}
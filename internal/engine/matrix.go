@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// MatrixCell describes the rule between a row substance and a column substance.
+type MatrixCell struct {
+	Type        domain.InteractionType `json:"type,omitempty"` // Empty = no known interaction
+	WindowHours float64                `json:"window_hours,omitempty"`
+	Note        string                 `json:"note,omitempty"`
+	Source      string                 `json:"source,omitempty"` // "direct" (row's own rule) or "reverse" (column's rule about the row)
+	Class       string                 `json:"class,omitempty"`  // Set when a class-level rule matched (e.g., "ssri")
+}
+
+// Matrix is an N x N interaction grid over a set of substances.
+type Matrix struct {
+	IDs   []string       `json:"ids"`
+	Names []string       `json:"names"`
+	Cells [][]MatrixCell `json:"cells"` // Cells[row][col]; the diagonal is always empty
+}
+
+// BuildMatrix looks up every ordered pair of 'ids' in the interaction graph.
+// A cell prefers the row's own rule about the column; if there is none, it falls
+// back to the column's rule about the row (marked "reverse"), mirroring the two
+// checks CheckSafety performs. Class-level rules count too, and name their class.
+func (a *Advisor) BuildMatrix(ids []string) (Matrix, error) {
+	defs := make([]domain.SubstanceDefinition, len(ids))
+	m := Matrix{IDs: ids, Names: make([]string, len(ids)), Cells: make([][]MatrixCell, len(ids))}
+
+	for i, id := range ids {
+		def, err := a.repo.GetDefinition(id)
+		if err != nil {
+			return Matrix{}, fmt.Errorf("unknown substance %s: %w", id, err)
+		}
+		defs[i] = def
+		m.Names[i] = def.Name
+	}
+
+	for i, row := range defs {
+		m.Cells[i] = make([]MatrixCell, len(defs))
+		for j, col := range defs {
+			if i == j {
+				continue
+			}
+			if rule, found := a.findInteraction(row, col); found {
+				m.Cells[i][j] = MatrixCell{Type: rule.Type, WindowHours: rule.WindowHours, Note: rule.Note, Source: "direct", Class: rule.TargetClass}
+			} else if rule, found := a.findInteraction(col, row); found {
+				m.Cells[i][j] = MatrixCell{Type: rule.Type, WindowHours: rule.WindowHours, Note: rule.Note, Source: "reverse", Class: rule.TargetClass}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Label is the short text form used in CSV and HTML tables (e.g., "INHIBIT 2h (reverse)",
+// "DANGEROUS 24h via ssri").
+func (c MatrixCell) Label() string {
+	if c.Type == "" {
+		return ""
+	}
+	label := fmt.Sprintf("%s %gh", c.Type, c.WindowHours)
+	if c.Class != "" {
+		label += " via " + c.Class
+	}
+	if c.Source == "reverse" {
+		label += " (reverse)"
+	}
+	return label
+}
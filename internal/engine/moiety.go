@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ActiveAmount converts a labelled amount of a salt form to its active moiety,
+// e.g. 500mg magnesium glycinate -> ~70mg magnesium. Anything else comes back unchanged.
+func (a *Advisor) ActiveAmount(substanceID string, labelledMg float64) (string, float64, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return "", 0, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+	if def.ActiveMoietyID == "" {
+		return def.ID, labelledMg, nil
+	}
+	if _, err := a.repo.GetDefinition(def.ActiveMoietyID); err != nil {
+		return "", 0, fmt.Errorf("salt form %s: unknown active moiety %s: %w", def.ID, def.ActiveMoietyID, err)
+	}
+	return def.ActiveMoietyID, labelledMg * activeFraction(def), nil
+}
+
+// ToActive rewrites a dose in terms of its active moiety, keeping what was labelled.
+func (a *Advisor) ToActive(dose domain.ActiveDose) (domain.ActiveDose, error) {
+	id, mg, err := a.ActiveAmount(dose.SubstanceID, dose.AmountMg)
+	if err != nil {
+		return dose, err
+	}
+	if id != dose.SubstanceID {
+		dose.LabelledID, dose.LabelledMg = dose.SubstanceID, dose.AmountMg
+		dose.SubstanceID, dose.AmountMg = id, mg
+	}
+	return dose, nil
+}
+
+func activeFraction(def domain.SubstanceDefinition) float64 {
+	if def.ActiveFraction <= 0 {
+		return 1
+	}
+	return def.ActiveFraction
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/sitanshunandan/glate/internal/domain"
 	"github.com/sitanshunandan/glate/internal/repository"
 	"github.com/sitanshunandan/glate/internal/store"
 )
@@ -14,6 +15,7 @@ type Monitor struct {
 	Store *store.SessionStore
 	Repo  repository.Repository
 	Calc  *MetabolicCalculator
+	Axes  []domain.LoadAxis // Cumulative load thresholds to watch (optional)
 }
 
 // NewMonitor creates the background worker.
@@ -51,6 +53,8 @@ func (m *Monitor) scan() {
 		}
 		fmt.Printf("User [%s]:\n", userID)
 
+		totals := make(map[string]float64)
+		var order []domain.SubstanceDefinition
 		for _, dose := range stack {
 			def, err := m.Repo.GetDefinition(dose.SubstanceID)
 			if err != nil {
@@ -59,6 +63,10 @@ func (m *Monitor) scan() {
 
 			elapsed := now.Sub(dose.IngestedAt)
 			remaining := m.Calc.RemainingAmount(dose.AmountMg, def.HalfLifeHours, elapsed)
+			if _, seen := totals[def.ID]; !seen {
+				order = append(order, def)
+			}
+			totals[def.ID] += remaining
 
 			// Fancy formatting: Visual bar for decay
 			// If remaining > 50%, show green. If low, show yellow.
@@ -66,11 +74,34 @@ func (m *Monitor) scan() {
 				def.Name, dose.AmountMg, remaining, elapsed.Minutes())
 
 			// ALERT LOGIC:
-			// If a stimulant drops below 50mg, log a "Sleep Window" alert
-			if def.Category == "Stimulant" && remaining < 50.0 && remaining > 40.0 {
+			// If a stimulant without a declared window drops below 50mg, log a "Sleep Window" alert
+			if def.Category == "Stimulant" && def.MinEffectiveMg <= 0 && remaining < 50.0 && remaining > 40.0 {
 				fmt.Printf("     💤 SLEEP WINDOW OPEN: %s is low enough.\n", def.Name)
 			}
 		}
+
+		// WINDOW LOGIC: judge each substance's combined amount against the catalog
+		for _, def := range order {
+			window := m.Calc.ClassifyWindow(def, totals[def.ID])
+			switch {
+			case window.Label == LabelUnclassified:
+				continue
+			case window.Label == LabelExcessive:
+				fmt.Printf("     🔥 %s is EXCESSIVE at %.1fmg (toxic %.0fmg)\n", def.Name, totals[def.ID], def.ToxicMg)
+			case window.Label == LabelSubtherapeutic && def.Category == domain.CatStimulant:
+				fmt.Printf("     💤 SLEEP WINDOW OPEN: %s is below its effective threshold.\n", def.Name)
+			case window.Label == LabelSubtherapeutic:
+				fmt.Printf("     ⬇️  %s is below its effective threshold.\n", def.Name)
+			}
+		}
+
+		// LOAD LOGIC: several mild contributors can add up to one big burden
+		loads := ComputeLoad(m.Repo, m.Calc, stack, now)
+		for _, axis := range m.Axes {
+			if loads[axis.ID] > axis.Threshold {
+				fmt.Printf("     ⚠️  %s at %.0f (threshold %.0f)\n", axis.Name, loads[axis.ID], axis.Threshold)
+			}
+		}
 	}
 	fmt.Println("---------------------------")
 }
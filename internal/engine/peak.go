@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// PeakProjection is the simulated maximum amount of a substance once a dose is added.
+type PeakProjection struct {
+	SubstanceID string    `json:"substance_id"`
+	PeakMg      float64   `json:"peak_mg"` // Projected Cmax (stack + proposed)
+	PeakAt      time.Time `json:"peak_at"`
+	ToxicMg     float64   `json:"toxic_mg,omitempty"`
+	Exceeds     bool      `json:"exceeds"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// ProjectPeak simulates 'proposed' on top of the stack and finds the peak of the
+// substance's combined amount. Absorption is modelled as instantaneous (as in the
+// rest of the engine), so the combined curve only rises at dose times: the peak is
+// at the proposed dose or at a later dose of the same substance already in 'stack'.
+func (a *Advisor) ProjectPeak(stack []domain.ActiveDose, proposed ProposedDose) (PeakProjection, error) {
+	def, err := a.repo.GetDefinition(proposed.SubstanceID)
+	if err != nil {
+		return PeakProjection{}, fmt.Errorf("unknown substance %s: %w", proposed.SubstanceID, err)
+	}
+
+	// 1. The substance's doses, including the proposed one
+	doses := []domain.ActiveDose{{SubstanceID: def.ID, AmountMg: proposed.AmountMg, IngestedAt: proposed.At}}
+	for _, dose := range stack {
+		if dose.SubstanceID == def.ID {
+			doses = append(doses, dose)
+		}
+	}
+
+	// 2. Evaluate the combined amount at every dose time from the proposal onwards
+	result := PeakProjection{SubstanceID: def.ID, ToxicMg: def.ToxicMg, PeakAt: proposed.At}
+	for _, candidate := range doses {
+		if candidate.IngestedAt.Before(proposed.At) {
+			continue
+		}
+		var total float64
+		for _, dose := range doses {
+			if elapsed := candidate.IngestedAt.Sub(dose.IngestedAt); elapsed >= 0 {
+				total += a.calc.RemainingAmount(dose.AmountMg, def.HalfLifeHours, elapsed)
+			}
+		}
+		if total > result.PeakMg {
+			result.PeakMg = total
+			result.PeakAt = candidate.IngestedAt
+		}
+	}
+
+	// 3. Compare against the catalog's toxic amount
+	if def.ToxicMg > 0 && result.PeakMg >= def.ToxicMg {
+		result.Exceeds = true
+		result.Message = fmt.Sprintf("%s would peak at %.0fmg at %s, over the %.0fmg toxic level",
+			def.Name, result.PeakMg, result.PeakAt.Format("15:04"), def.ToxicMg)
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Suggestion is a "take these together" hint derived from POTENTIATE edges.
+type Suggestion struct {
+	SubstanceID string  `json:"substance_id"` // The helper substance (e.g., Vitamin C)
+	Name        string  `json:"name"`
+	TargetID    string  `json:"target_id"`      // The proposed substance it boosts
+	WindowMin   float64 `json:"window_minutes"` // Take the helper within this many minutes
+	InRegimen   bool    `json:"in_regimen"`     // Already part of the user's planned stack
+	Message     string  `json:"message"`
+	Note        string  `json:"note"` // Clinical explanation from the edge
+}
+
+// Suggest finds substances that potentiate 'proposedID'.
+// Substances listed in 'regimen' are flagged and ranked first, since the user
+// is already planning to take them anyway.
+func (a *Advisor) Suggest(proposedID string, regimen []string) ([]Suggestion, error) {
+	target, err := a.repo.GetDefinition(proposedID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown substance %s: %w", proposedID, err)
+	}
+
+	all, err := a.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make(map[string]bool, len(regimen))
+	for _, id := range regimen {
+		planned[id] = true
+	}
+
+	var suggestions []Suggestion
+	for _, def := range all {
+		if def.ID == proposedID {
+			continue
+		}
+		rule, found := a.findInteraction(def, target)
+		if !found || rule.Type != domain.TypePotentiate {
+			continue
+		}
+
+		window := time.Duration(rule.WindowHours * float64(time.Hour))
+		suggestions = append(suggestions, Suggestion{
+			SubstanceID: def.ID,
+			Name:        def.Name,
+			TargetID:    target.ID,
+			WindowMin:   window.Minutes(),
+			InRegimen:   planned[def.ID],
+			Message:     fmt.Sprintf("Take %s within %.0f minutes of %s", def.Name, window.Minutes(), target.Name),
+			Note:        rule.Note,
+		})
+	}
+
+	// Regimen items first, then alphabetical for stable output
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].InRegimen != suggestions[j].InRegimen {
+			return suggestions[i].InRegimen
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+
+	return suggestions, nil
+}
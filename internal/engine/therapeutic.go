@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// WindowLabel places an active amount relative to the substance's therapeutic window.
+type WindowLabel string
+
+const (
+	LabelUnclassified   WindowLabel = ""               // The catalog declares no window
+	LabelSubtherapeutic WindowLabel = "subtherapeutic" // Below the minimum effective amount
+	LabelTherapeutic    WindowLabel = "therapeutic"    // Effective, below toxic
+	LabelExcessive      WindowLabel = "excessive"      // At or above the toxic amount
+)
+
+// WindowStatus is the current label plus when decay will move it to the next one.
+type WindowStatus struct {
+	Label     WindowLabel   `json:"label,omitempty"`
+	NextLabel WindowLabel   `json:"next_label,omitempty"`
+	ChangesIn time.Duration `json:"-"` // Zero when the label will not change by decay alone
+}
+
+// ClassifyWindow labels the summed active amount of one substance.
+// All doses of a substance share its half-life, so their sum decays as a single
+// exponential and the time to the next boundary is exact.
+func (c *MetabolicCalculator) ClassifyWindow(def domain.SubstanceDefinition, totalMg float64) WindowStatus {
+	if def.MinEffectiveMg <= 0 && def.ToxicMg <= 0 {
+		return WindowStatus{}
+	}
+
+	switch {
+	case def.ToxicMg > 0 && totalMg >= def.ToxicMg:
+		status := WindowStatus{Label: LabelExcessive, NextLabel: LabelTherapeutic}
+		if def.MinEffectiveMg >= def.ToxicMg {
+			status.NextLabel = LabelSubtherapeutic
+		}
+		status.ChangesIn = c.TimeUntilClearance(totalMg, def.ToxicMg, def.HalfLifeHours)
+		return status
+
+	case totalMg >= def.MinEffectiveMg && def.MinEffectiveMg > 0:
+		return WindowStatus{
+			Label:     LabelTherapeutic,
+			NextLabel: LabelSubtherapeutic,
+			ChangesIn: c.TimeUntilClearance(totalMg, def.MinEffectiveMg, def.HalfLifeHours),
+		}
+
+	case def.MinEffectiveMg > 0:
+		return WindowStatus{Label: LabelSubtherapeutic}
+
+	default:
+		// Only a toxic level is declared: anything below it counts as therapeutic
+		return WindowStatus{Label: LabelTherapeutic}
+	}
+}
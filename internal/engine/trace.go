@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Direction records which side of a pair owns the rule that fired.
+type Direction string
+
+const (
+	// DirActiveToProposed: the substance already in the body declares the rule (e.g., Caffeine -> Iron).
+	DirActiveToProposed Direction = "active->proposed"
+
+	// DirProposedToActive: the proposed substance declares the rule (the old "Reverse Conflict").
+	DirProposedToActive Direction = "proposed->active"
+)
+
+// ConflictTrace explains why a conflict fired, down to the window arithmetic.
+type ConflictTrace struct {
+	Direction      Direction `json:"direction"`
+	RuleOwner      string    `json:"rule_owner"`      // Substance ID whose interaction list holds the rule
+	RuleTarget     string    `json:"rule_target"`     // The edge's target_id, or "class:<name>"
+	ResolutionPath []string  `json:"resolution_path"` // Owner -> target, or owner -> class -> target for class-level rules
+
+	TriggerDoseID string    `json:"trigger_dose_id,omitempty"` // The active dose that triggered it
+	TriggerDoseMg float64   `json:"trigger_dose_mg"`
+	IngestedAt    time.Time `json:"ingested_at"`
+	RemainingMg   float64   `json:"remaining_mg"` // What is left of the trigger dose right now
+	ProposedMg    float64   `json:"proposed_mg"`
+
+	ElapsedHours    float64 `json:"elapsed_hours"`
+	BaseWindowHours float64 `json:"base_window_hours"` // window_hours as written in the catalog
+	WindowHours     float64 `json:"window_hours"`      // After dose scaling and capping
+	MinSourceMg     float64 `json:"min_source_mg,omitempty"`
+	MinTargetMg     float64 `json:"min_target_mg,omitempty"`
+	WindowScaleMg   float64 `json:"window_scale_mg,omitempty"`
+
+	Steps []string `json:"steps"` // Human-readable arithmetic, in evaluation order
+}
+
+// Explain renders the trace as indented lines for terminals and logs.
+func (t ConflictTrace) Explain() []string {
+	lines := []string{
+		fmt.Sprintf("Rule: %s (%s)", strings.Join(t.ResolutionPath, " -> "), t.Direction),
+		fmt.Sprintf("Trigger: %.0fmg taken %s, %.1fmg remaining", t.TriggerDoseMg, t.IngestedAt.Format(time.Kitchen), t.RemainingMg),
+	}
+	return append(lines, t.Steps...)
+}
+
+// buildTrace assembles the explanation for a rule that fired between an active
+// dose and the proposed substance. 'target' is the substance the rule matched,
+// and sourceMg is the dose of the rule owner.
+func buildTrace(dir Direction, owner, target string, rule domain.Interaction, dose domain.ActiveDose, remainingMg, proposedMg, sourceMg float64, elapsed, window time.Duration) ConflictTrace {
+	t := ConflictTrace{
+		Direction:       dir,
+		RuleOwner:       owner,
+		RuleTarget:      rule.Target(),
+		ResolutionPath:  resolutionPath(owner, rule, target),
+		TriggerDoseID:   dose.ID,
+		TriggerDoseMg:   dose.AmountMg,
+		IngestedAt:      dose.IngestedAt,
+		RemainingMg:     remainingMg,
+		ProposedMg:      proposedMg,
+		ElapsedHours:    elapsed.Hours(),
+		BaseWindowHours: rule.WindowHours,
+		WindowHours:     window.Hours(),
+		MinSourceMg:     rule.MinSourceMg,
+		MinTargetMg:     rule.MinTargetMg,
+		WindowScaleMg:   rule.WindowScaleMg,
+	}
+
+	// 1. Thresholds (only worth mentioning when the rule has them)
+	targetMg := proposedMg
+	if dir == DirProposedToActive {
+		targetMg = dose.AmountMg
+	}
+	// An unknown dose (0) skips the threshold in ruleApplies, so say that instead
+	if rule.MinSourceMg > 0 {
+		t.Steps = append(t.Steps, thresholdStep("Owner", sourceMg, rule.MinSourceMg))
+	}
+	if rule.MinTargetMg > 0 {
+		t.Steps = append(t.Steps, thresholdStep("Target", targetMg, rule.MinTargetMg))
+	}
+
+	// 2. Window
+	if rule.WindowScaleMg > 0 && sourceMg > 0 {
+		t.Steps = append(t.Steps, fmt.Sprintf("Window = %.1fh x (%.0fmg / %.0fmg) = %.1fh",
+			rule.WindowHours, sourceMg, rule.WindowScaleMg, rule.WindowHours*sourceMg/rule.WindowScaleMg))
+		if rule.MaxWindowHours > 0 && window.Hours() < rule.WindowHours*sourceMg/rule.WindowScaleMg {
+			t.Steps = append(t.Steps, fmt.Sprintf("Window capped at %.1fh", rule.MaxWindowHours))
+		}
+	} else {
+		t.Steps = append(t.Steps, fmt.Sprintf("Window = %.1fh (fixed)", window.Hours()))
+	}
+
+	// 3. Verdict
+	t.Steps = append(t.Steps, fmt.Sprintf("Elapsed %.1fh < window %.1fh -> wait %s",
+		elapsed.Hours(), window.Hours(), (window-elapsed).Round(time.Minute)))
+
+	return t
+}
+
+func thresholdStep(side string, mg, threshold float64) string {
+	if mg <= 0 {
+		return fmt.Sprintf("%s dose unknown, threshold %.0fmg not applied", side, threshold)
+	}
+	return fmt.Sprintf("%s dose %.0fmg >= threshold %.0fmg", side, mg, threshold)
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ToMg converts an amount of 'substanceID' to milligrams, using the
+// substance's own conversions for IU and mL.
+func (a *Advisor) ToMg(substanceID string, amount domain.Amount) (float64, error) {
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return 0, fmt.Errorf("unknown substance %s: %w", substanceID, err)
+	}
+	mg, err := amount.ToMg(&def)
+	if err != nil {
+		return 0, err
+	}
+	if mg < 0 {
+		return 0, fmt.Errorf("amount cannot be negative, got %s", amount)
+	}
+	return mg, nil
+}
+
+// Display renders 'mg' of 'substanceID' in the unit the profile prefers for it.
+// It returns nil when there is no preference or the unit doesn't convert.
+func (a *Advisor) Display(substanceID string, mg float64, profile domain.UserProfile) *domain.Amount {
+	unit, ok := profile.PreferredUnits[substanceID]
+	if !ok {
+		return nil
+	}
+	def, err := a.repo.GetDefinition(substanceID)
+	if err != nil {
+		return nil
+	}
+	amount, err := domain.AmountFromMg(mg, unit, &def)
+	if err != nil {
+		return nil
+	}
+	return &amount
+}
+
+// DisplayDose is Display for a dose. A preference set for the labelled
+// substance (e.g., a salt form) wins over the one for its active moiety.
+func (a *Advisor) DisplayDose(dose domain.ActiveDose, profile domain.UserProfile) *domain.Amount {
+	if dose.LabelledID != "" {
+		if amount := a.Display(dose.LabelledID, dose.LabelledMg, profile); amount != nil {
+			return amount
+		}
+	}
+	return a.Display(dose.SubstanceID, dose.AmountMg, profile)
+}
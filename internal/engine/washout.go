@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ClearanceCriterion defines when a substance counts as "washed out".
+type ClearanceCriterion struct {
+	HalfLives float64 `json:"half_lives,omitempty"` // Below 2^-n of the compound's peak (e.g., 5 half-lives ~ 3%)
+	BelowMg   float64 `json:"below_mg,omitempty"`   // Below an absolute amount; wins over HalfLives if set
+}
+
+// SpeciesClearance is the clearance of one compound: the parent or one of its metabolites.
+type SpeciesClearance struct {
+	SubstanceID   string    `json:"substance_id"`
+	Role          string    `json:"role"`            // "parent" or "metabolite"
+	HalfLifeHours float64   `json:"half_life_hours"` // After personal overrides
+	PeakMg        float64   `json:"peak_mg"`
+	ThresholdMg   float64   `json:"threshold_mg"`
+	ClearAt       time.Time `json:"clear_at"`
+}
+
+// WashoutEntry covers one substance in the stack that is DANGEROUS with the target.
+type WashoutEntry struct {
+	SubstanceID  string             `json:"substance_id"`
+	Name         string             `json:"name"`
+	Reason       string             `json:"reason"`
+	ClearAt      time.Time          `json:"clear_at"`      // When what is already taken has cleared
+	WashoutHours float64            `json:"washout_hours"` // How long one more dose would take to clear
+	LastSafeDose time.Time          `json:"last_safe_dose"`
+	Species      []SpeciesClearance `json:"species"`
+}
+
+// WashoutPlan is the answer to "when can I switch to the target?".
+type WashoutPlan struct {
+	TargetID      string             `json:"target_id"`
+	Criterion     ClearanceCriterion `json:"criterion"`
+	EarliestStart time.Time          `json:"earliest_start"` // If every conflicting substance is stopped now
+	StartAt       time.Time          `json:"start_at"`       // The start LastSafeDose is planned against
+	Entries       []WashoutEntry     `json:"entries"`
+	Timing        []TimeViolation    `json:"timing,omitempty"` // Time-of-day rules the target breaks at StartAt
+	Message       string             `json:"message"`
+}
+
+// DefaultCriterion is the usual pharmacology rule of thumb.
+var DefaultCriterion = ClearanceCriterion{HalfLives: 5}
+
+// PlanWashout finds every substance in the stack that is DANGEROUS with 'targetID'
+// (directly or through one of its metabolites) and computes when it has cleared.
+// If 'startAt' is zero, the plan targets the earliest possible start.
+func (a *Advisor) PlanWashout(stack []domain.ActiveDose, targetID string, crit ClearanceCriterion, profile domain.UserProfile, startAt, now time.Time) (WashoutPlan, error) {
+	if crit.HalfLives <= 0 && crit.BelowMg <= 0 {
+		crit = DefaultCriterion
+	}
+	target, err := a.repo.GetDefinition(targetID)
+	if err != nil {
+		return WashoutPlan{}, fmt.Errorf("unknown substance %s: %w", targetID, err)
+	}
+
+	plan := WashoutPlan{TargetID: target.ID, Criterion: crit, EarliestStart: now}
+
+	// 1. Group the stack by substance, keeping the first-seen order
+	var order []string
+	doses := make(map[string][]domain.ActiveDose)
+	for _, dose := range stack {
+		if _, seen := doses[dose.SubstanceID]; !seen {
+			order = append(order, dose.SubstanceID)
+		}
+		doses[dose.SubstanceID] = append(doses[dose.SubstanceID], dose)
+	}
+
+	// 2. Work out the clearance of every conflicting substance
+	for _, id := range order {
+		def, err := a.repo.GetDefinition(id)
+		if err != nil {
+			return WashoutPlan{}, fmt.Errorf("unknown active substance %s: %w", id, err)
+		}
+		reason, dangerous := a.dangerousWith(def, target)
+		if !dangerous {
+			continue
+		}
+
+		entry := WashoutEntry{SubstanceID: def.ID, Name: def.Name, Reason: reason}
+		species, err := a.species(def, profile)
+		if err != nil {
+			return WashoutPlan{}, err
+		}
+
+		// 2a. What is already in the body
+		for _, sp := range species {
+			c := a.clearance(sp, doses[id], crit)
+			entry.Species = append(entry.Species, c)
+			if c.ClearAt.After(entry.ClearAt) {
+				entry.ClearAt = c.ClearAt
+			}
+		}
+
+		// 2b. How long one more dose (the largest so far) would need
+		largest := domain.ActiveDose{SubstanceID: id, IngestedAt: now}
+		for _, d := range doses[id] {
+			largest.AmountMg = math.Max(largest.AmountMg, d.AmountMg)
+		}
+		for _, sp := range species {
+			c := a.clearance(sp, []domain.ActiveDose{largest}, crit)
+			entry.WashoutHours = math.Max(entry.WashoutHours, c.ClearAt.Sub(now).Hours())
+		}
+
+		if entry.ClearAt.After(plan.EarliestStart) {
+			plan.EarliestStart = entry.ClearAt
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+
+	// 3. Last safe dose of each old substance, relative to the planned start
+	plan.StartAt = startAt
+	if plan.StartAt.IsZero() || plan.StartAt.Before(plan.EarliestStart) {
+		plan.StartAt = plan.EarliestStart
+	}
+	for i := range plan.Entries {
+		washout := time.Duration(plan.Entries[i].WashoutHours * float64(time.Hour))
+		plan.Entries[i].LastSafeDose = plan.StartAt.Add(-washout)
+	}
+
+	// 4. A start that is safe pharmacologically can still be the wrong time of day
+	if plan.Timing, err = a.CheckTiming(target.ID, plan.StartAt, profile); err != nil {
+		return WashoutPlan{}, err
+	}
+
+	if len(plan.Entries) == 0 {
+		plan.Message = fmt.Sprintf("Nothing in the stack is dangerous with %s; no washout needed", target.Name)
+	} else {
+		plan.Message = fmt.Sprintf("Earliest safe start for %s: %s", target.Name, plan.EarliestStart.Format(time.RFC3339))
+	}
+	if len(plan.Timing) > 0 {
+		plan.Message += fmt.Sprintf(" (the start breaks %d time-of-day rule(s); see timing)", len(plan.Timing))
+	}
+	return plan, nil
+}
+
+// dangerousWith checks both directions, plus the substance's metabolites.
+func (a *Advisor) dangerousWith(def, target domain.SubstanceDefinition) (string, bool) {
+	candidates := []domain.SubstanceDefinition{def}
+	for _, met := range def.Metabolites {
+		if metDef, err := a.repo.GetDefinition(met.SubstanceID); err == nil {
+			candidates = append(candidates, metDef)
+		}
+	}
+
+	for _, c := range candidates {
+		if rule, found := a.findInteraction(c, target); found && rule.Type == domain.TypeDangerous {
+			return rule.Note, true
+		}
+		if rule, found := a.findInteraction(target, c); found && rule.Type == domain.TypeDangerous {
+			return rule.Note, true
+		}
+	}
+	return "", false
+}
+
+// washoutSpecies is a compound to track: the parent itself or a metabolite of it.
+type washoutSpecies struct {
+	id           string
+	role         string
+	fraction     float64
+	parentHL     float64
+	halfLife     float64
+	isMetabolite bool
+}
+
+// species lists the parent and its metabolites with personal half-lives applied.
+func (a *Advisor) species(def domain.SubstanceDefinition, profile domain.UserProfile) ([]washoutSpecies, error) {
+	parentHL := personalHalfLife(def, profile)
+	list := []washoutSpecies{{id: def.ID, role: "parent", parentHL: parentHL, halfLife: parentHL}}
+
+	for _, met := range def.Metabolites {
+		metDef, err := a.repo.GetDefinition(met.SubstanceID)
+		if err != nil {
+			return nil, fmt.Errorf("unknown metabolite %s of %s: %w", met.SubstanceID, def.ID, err)
+		}
+		list = append(list, washoutSpecies{
+			id:           metDef.ID,
+			role:         "metabolite",
+			fraction:     met.Fraction,
+			parentHL:     parentHL,
+			halfLife:     personalHalfLife(metDef, profile),
+			isMetabolite: true,
+		})
+	}
+	return list, nil
+}
+
+// personalHalfLife prefers the user's measured half-life over the catalog value.
+func personalHalfLife(def domain.SubstanceDefinition, profile domain.UserProfile) float64 {
+	if hours, ok := profile.HalfLifeOverrides[def.ID]; ok && hours > 0 {
+		return hours
+	}
+	return def.HalfLifeHours
+}
+
+// clearance scans the compound's curve and returns the first moment after which it
+// stays under the criterion. Metabolites rise before they fall, so a closed form
+// won't do; a fine time grid over ~40 half-lives is plenty for planning.
+func (a *Advisor) clearance(sp washoutSpecies, doses []domain.ActiveDose, crit ClearanceCriterion) SpeciesClearance {
+	result := SpeciesClearance{SubstanceID: sp.id, Role: sp.role, HalfLifeHours: sp.halfLife}
+	if len(doses) == 0 {
+		return result
+	}
+
+	first, last := doses[0].IngestedAt, doses[0].IngestedAt
+	for _, d := range doses {
+		if d.IngestedAt.Before(first) {
+			first = d.IngestedAt
+		}
+		if d.IngestedAt.After(last) {
+			last = d.IngestedAt
+		}
+	}
+
+	amountAt := func(t time.Time) float64 {
+		var total float64
+		for _, d := range doses {
+			elapsed := t.Sub(d.IngestedAt)
+			if elapsed < 0 {
+				continue
+			}
+			if sp.isMetabolite {
+				total += a.calc.MetaboliteAmount(d.AmountMg, sp.fraction, sp.parentHL, sp.halfLife, elapsed)
+			} else {
+				total += a.calc.RemainingAmount(d.AmountMg, sp.halfLife, elapsed)
+			}
+		}
+		return total
+	}
+
+	// 1. Sample the curve from the first dose well past the last one
+	horizon := last.Sub(first) + time.Duration(40*math.Max(sp.halfLife, sp.parentHL)*float64(time.Hour))
+	step := max(horizon/20000, time.Minute)
+	var samples []float64
+	for t := first; !t.After(first.Add(horizon)); t = t.Add(step) {
+		amount := amountAt(t)
+		samples = append(samples, amount)
+		result.PeakMg = math.Max(result.PeakMg, amount)
+	}
+
+	// 2. Threshold: absolute, or relative to the peak
+	result.ThresholdMg = crit.BelowMg
+	if result.ThresholdMg <= 0 {
+		result.ThresholdMg = result.PeakMg * math.Pow(2, -crit.HalfLives)
+	}
+
+	// 3. The last sample above the threshold marks the end of the washout
+	result.ClearAt = first
+	for i := len(samples) - 1; i >= 0; i-- {
+		if samples[i] > result.ThresholdMg {
+			result.ClearAt = first.Add(time.Duration(i+1) * step)
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Versioned is implemented by repositories that number their catalog versions.
+type Versioned interface {
+	Version() int
+	History() []CatalogVersion
+}
+
+// WritableRepository is a catalog that can be edited at runtime.
+// Every successful change is validated and produces a new version.
+// Writes edit the base file; override layers keep applying on top.
+type WritableRepository interface {
+	Repository
+	Versioned
+	Create(def domain.SubstanceDefinition) (CatalogVersion, error)
+	Update(def domain.SubstanceDefinition) (CatalogVersion, error)
+	Delete(id string) (CatalogVersion, error)
+}
+
+// CatalogVersion is one entry of the catalog's change history.
+type CatalogVersion struct {
+	Version     int         `json:"version"`
+	At          time.Time   `json:"at"`
+	Action      string      `json:"action"` // load, reload, create, update, delete
+	SubstanceID string      `json:"substance_id,omitempty"`
+	Diff        CatalogDiff `json:"diff"`
+}
+
+// ErrConflict / ErrNotFound / ErrReadOnly let callers map write failures to status codes.
+var (
+	ErrConflict = fmt.Errorf("substance already exists")
+	ErrNotFound = fmt.Errorf("substance not found")
+	ErrReadOnly = fmt.Errorf("catalog is read-only")
+)
+
+// Version returns the current catalog version.
+func (r *InMemoryRepo) Version() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// History returns a copy of every version, oldest first.
+func (r *InMemoryRepo) History() []CatalogVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.history)
+}
+
+// Create adds a new substance.
+func (r *InMemoryRepo) Create(def domain.SubstanceDefinition) (CatalogVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.data[def.ID]; exists {
+		return CatalogVersion{}, fmt.Errorf("%w: %s", ErrConflict, def.ID)
+	}
+	return r.applyLocked("create", def.ID, append(slices.Clone(r.order), def.ID), def)
+}
+
+// Update replaces an existing substance.
+func (r *InMemoryRepo) Update(def domain.SubstanceDefinition) (CatalogVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.base[def.ID]; !exists {
+		return CatalogVersion{}, fmt.Errorf("%w: %s (not in the base catalog)", ErrNotFound, def.ID)
+	}
+	return r.applyLocked("update", def.ID, r.order, def)
+}
+
+// Delete removes a substance. Validation catches edges left dangling by it.
+func (r *InMemoryRepo) Delete(id string) (CatalogVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.base[id]; !exists {
+		return CatalogVersion{}, fmt.Errorf("%w: %s (not in the base catalog)", ErrNotFound, id)
+	}
+	order := slices.DeleteFunc(slices.Clone(r.order), func(x string) bool { return x == id })
+	return r.applyLocked("delete", id, order, domain.SubstanceDefinition{})
+}
+
+// applyLocked builds the candidate base catalog, validates it, persists it and swaps it in.
+// 'def' (if it has an ID) replaces or adds that substance.
+func (r *InMemoryRepo) applyLocked(action, id string, order []string, def domain.SubstanceDefinition) (CatalogVersion, error) {
+	// The embedded catalog has nowhere to persist to, and a reload would drop the edit
+	if r.path == "" {
+		return CatalogVersion{}, fmt.Errorf("%w: the embedded catalog can't be edited; start with a catalog file", ErrReadOnly)
+	}
+
+	// 1. Candidate base file in file order
+	candidate := make([]domain.SubstanceDefinition, 0, len(order))
+	for _, oid := range order {
+		if def.ID != "" && oid == def.ID {
+			candidate = append(candidate, def)
+		} else {
+			candidate = append(candidate, r.base[oid])
+		}
+	}
+
+	// 2. Same gate as a reload, on the catalog with the overrides still on top
+	effective, provenance, err := composeCatalog(candidate, r.overrides)
+	if err != nil {
+		return CatalogVersion{}, err
+	}
+	issues, err := r.validateLocked(effective)
+	if err != nil {
+		return CatalogVersion{}, err
+	}
+
+	// 3. Persist first, so a reload can't resurrect the old version
+	if err := r.persistLocked(candidate); err != nil {
+		return CatalogVersion{}, err
+	}
+
+	diff := r.installLocked(candidate, r.overrides, effective, provenance, issues)
+	return r.recordLocked(action, id, diff), nil
+}
+
+// persistLocked atomically rewrites the source file (temp file + rename).
+func (r *InMemoryRepo) persistLocked(definitions []domain.SubstanceDefinition) error {
+	raw, err := json.MarshalIndent(definitions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".substances-*.json")
+	if err != nil {
+		return fmt.Errorf("persist catalog: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("persist catalog: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("persist catalog: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("persist catalog: %w", err)
+	}
+
+	// Our own write is not an external edit: keep the watcher quiet
+	r.modTime = sourcesModTime(r.path, r.overrideDir)
+	return nil
+}
+
+// recordLocked bumps the version and appends a history entry.
+func (r *InMemoryRepo) recordLocked(action, id string, diff CatalogDiff) CatalogVersion {
+	r.version++
+	entry := CatalogVersion{Version: r.version, At: time.Now(), Action: action, SubstanceID: id, Diff: diff}
+	r.history = append(r.history, entry)
+	return entry
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+func TestWritesAreValidatedAndVersioned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "substances.json")
+	writeCatalog(t, path, `[{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99}]`)
+
+	repo, err := NewInMemoryRepo(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Version() != 1 {
+		t.Fatalf("Expected version 1 after load, got %d", repo.Version())
+	}
+
+	// 1. A valid create bumps the version and lands on disk
+	nac := domain.SubstanceDefinition{ID: "nac", Name: "NAC", Category: "AminoAcid", HalfLifeHours: 5.6, Bioavailability: 0.1}
+	if _, err := repo.Create(nac); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	onDisk, err := LoadDefinitions(path)
+	if err != nil || len(onDisk) != 2 || onDisk[1].ID != "nac" {
+		t.Errorf("Expected the file to be rewritten in order, got %+v (%v)", onDisk, err)
+	}
+
+	// 2. An edge to nowhere is rejected and changes nothing
+	bad := nac
+	bad.Interactions = []domain.Interaction{{TargetID: "ghost", Type: domain.TypeDangerous, WindowHours: 2}}
+	if _, err := repo.Update(bad); err == nil {
+		t.Fatalf("Expected the dangling edge to be rejected")
+	}
+	if repo.Version() != 2 {
+		t.Errorf("Expected a rejected write to keep version 2, got %d", repo.Version())
+	}
+
+	// 3. Delete, then the history tells the whole story
+	if _, err := repo.Delete("nac"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actions []string
+	for _, v := range repo.History() {
+		actions = append(actions, v.Action)
+	}
+	if len(actions) != 3 || actions[0] != "load" || actions[1] != "create" || actions[2] != "delete" {
+		t.Errorf("Unexpected history: %v", actions)
+	}
+}
+
+func TestWatcherIgnoresOwnWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "substances.json")
+	writeCatalog(t, path, `[{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99}]`)
+	repo, err := NewInMemoryRepo(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Backdate the file, so the write below gets a distinguishable mtime
+	lastSeen := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, lastSeen, lastSeen); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	repo.modTime = lastSeen
+
+	if _, err := repo.Create(domain.SubstanceDefinition{ID: "nac", Name: "NAC", Category: "AminoAcid", HalfLifeHours: 5.6, Bioavailability: 0.1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, changed := repo.sourcesChanged(lastSeen); changed {
+		t.Error("Expected our own write not to count as an external edit")
+	}
+
+	// Someone else touching the file does
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if _, changed := repo.sourcesChanged(lastSeen); !changed {
+		t.Error("Expected an external edit to be noticed")
+	}
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Layer names used in provenance reports.
+const (
+	LayerBase           = "base"
+	LayerOverridePrefix = "override:" // + file name inside the override directory
+	LayerUserPrefix     = "user:"     // + user ID
+)
+
+// Layer is one source of catalog data on top of the base file.
+// Entries are partial substance objects: only the fields present replace
+// the ones below. Fields merge at the top level, so an override that sets
+// "interactions" replaces the whole list.
+type Layer struct {
+	Name    string
+	Entries map[string]map[string]json.RawMessage // Substance ID -> field -> raw JSON
+	order   []string                              // IDs in file order
+}
+
+// Provenance maps each JSON field of a substance to the layer it came from.
+type Provenance map[string]string
+
+// Layered is implemented by repositories that compose several catalog sources.
+type Layered interface {
+	ForUser(userID string) Repository
+	Provenance(userID, id string) (Provenance, error)
+	UserDefinitions(userID string) []json.RawMessage
+	SetUserDefinition(userID string, entry json.RawMessage) (domain.SubstanceDefinition, error)
+	DeleteUserDefinition(userID, id string) error
+}
+
+// WithOverrideDir layers every *.json file in 'dir' on top of the base catalog,
+// in file-name order (later files win).
+func WithOverrideDir(dir string) Option {
+	return func(r *InMemoryRepo) {
+		r.overrideDir = dir
+	}
+}
+
+// ParseLayer decodes a JSON array of partial substance objects. Every entry needs an "id".
+func ParseLayer(name string, raw []byte) (Layer, error) {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return Layer{}, fmt.Errorf("layer %s: invalid JSON format: %w", name, err)
+	}
+
+	layer := Layer{Name: name, Entries: make(map[string]map[string]json.RawMessage)}
+	for i, entry := range entries {
+		if err := layer.set(entry); err != nil {
+			return Layer{}, fmt.Errorf("layer %s: entry %d: %w", name, i, err)
+		}
+	}
+	return layer, nil
+}
+
+// LoadOverrideDir reads the override layers. An empty 'dir' means no overrides.
+func LoadOverrideDir(dir string) ([]Layer, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	var layers []Layer
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		layer, err := ParseLayer(LayerOverridePrefix+filepath.Base(file), raw)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// set adds or replaces one entry, keyed by its "id" field.
+func (l *Layer) set(entry map[string]json.RawMessage) error {
+	var id string
+	if err := json.Unmarshal(entry["id"], &id); err != nil || id == "" {
+		return fmt.Errorf("missing or invalid \"id\"")
+	}
+	if _, exists := l.Entries[id]; !exists {
+		l.order = append(l.order, id)
+	}
+	l.Entries[id] = entry
+	return nil
+}
+
+// composeCatalog applies the layers to the base definitions, in order.
+// Base entries keep their position (duplicates included, so the linter still
+// sees them); substances that only exist in a layer are appended.
+func composeCatalog(base []domain.SubstanceDefinition, layers []Layer) ([]domain.SubstanceDefinition, map[string]Provenance, error) {
+	effective := make([]domain.SubstanceDefinition, 0, len(base))
+	provenance := make(map[string]Provenance)
+
+	// 1. Base entries, with every layer's fields on top
+	for _, def := range base {
+		fields, err := toFields(def)
+		if err != nil {
+			return nil, nil, err
+		}
+		prov := make(Provenance, len(fields))
+		for field := range fields {
+			prov[field] = LayerBase
+		}
+		merged, err := overlay(def.ID, fields, prov, layers)
+		if err != nil {
+			return nil, nil, err
+		}
+		effective = append(effective, merged)
+		provenance[def.ID] = prov
+	}
+
+	// 2. Substances introduced by a layer
+	for _, layer := range layers {
+		for _, id := range layer.order {
+			if _, known := provenance[id]; known {
+				continue
+			}
+			prov := make(Provenance)
+			merged, err := overlay(id, map[string]json.RawMessage{}, prov, layers)
+			if err != nil {
+				return nil, nil, err
+			}
+			effective = append(effective, merged)
+			provenance[id] = prov
+		}
+	}
+	return effective, provenance, nil
+}
+
+// overlay writes every layer's fields for 'id' over 'fields' and decodes the result.
+func overlay(id string, fields map[string]json.RawMessage, prov Provenance, layers []Layer) (domain.SubstanceDefinition, error) {
+	for _, layer := range layers {
+		for field, raw := range layer.Entries[id] {
+			if field == "id" && prov["id"] != "" {
+				continue // Every entry repeats the ID; it belongs to whoever introduced it
+			}
+			fields[field] = raw
+			prov[field] = layer.Name
+		}
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return domain.SubstanceDefinition{}, err
+	}
+	var def domain.SubstanceDefinition
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return domain.SubstanceDefinition{}, fmt.Errorf("substance %s: layered fields don't decode: %w", id, err)
+	}
+	return def, nil
+}
+
+// toFields splits a definition into its top-level JSON fields.
+func toFields(def domain.SubstanceDefinition) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(raw, &fields)
+	return fields, err
+}
+
+// -------------------------------------------------------------------------
+// Per-user private definitions (in memory, like profiles)
+// -------------------------------------------------------------------------
+
+// ForUser returns the catalog as 'userID' sees it: the shared catalog plus
+// their private definitions. Users without any get the shared repository.
+func (r *InMemoryRepo) ForUser(userID string) Repository {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	layer, ok := r.private[userID]
+	if !ok {
+		return r
+	}
+	effective, _, err := composeCatalog(r.effectiveLocked(), []Layer{layer})
+	if err != nil {
+		// Validated on write; only a base change can get us here
+		log.Printf("⚠️  Private definitions of [%s] no longer apply, using the shared catalog: %v", userID, err)
+		return r
+	}
+	return userView{staticRepo: indexDefinitions(effective), search: NewSearchIndex(effective)}
+}
+
+// Provenance reports which layer each field of a substance came from.
+// With a user ID, their private layer is included.
+func (r *InMemoryRepo) Provenance(userID, id string) (Provenance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prov := make(Provenance)
+	for field, layer := range r.provenance[id] {
+		prov[field] = layer
+	}
+	for field := range r.private[userID].Entries[id] {
+		if field == "id" && prov["id"] != "" {
+			continue
+		}
+		prov[field] = LayerUserPrefix + userID
+	}
+	if len(prov) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return prov, nil
+}
+
+// UserDefinitions lists a user's private entries as stored.
+func (r *InMemoryRepo) UserDefinitions(userID string) []json.RawMessage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	layer := r.private[userID]
+	entries := make([]json.RawMessage, 0, len(layer.order))
+	for _, id := range layer.order {
+		raw, _ := json.Marshal(layer.Entries[id])
+		entries = append(entries, raw)
+	}
+	return entries
+}
+
+// SetUserDefinition adds or replaces one private entry. The user's view of the
+// catalog is validated like any other change and returned merged.
+func (r *InMemoryRepo) SetUserDefinition(userID string, entry json.RawMessage) (domain.SubstanceDefinition, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return domain.SubstanceDefinition{}, fmt.Errorf("invalid JSON format: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 1. Candidate layer: a copy, so a rejected entry leaves no trace
+	layer := Layer{Name: LayerUserPrefix + userID, Entries: make(map[string]map[string]json.RawMessage)}
+	if current, ok := r.private[userID]; ok {
+		layer.order = slices.Clone(current.order)
+		for id, e := range current.Entries {
+			layer.Entries[id] = e
+		}
+	}
+	if err := layer.set(fields); err != nil {
+		return domain.SubstanceDefinition{}, err
+	}
+
+	// 2. Validate the user's whole view
+	effective, _, err := composeCatalog(r.effectiveLocked(), []Layer{layer})
+	if err != nil {
+		return domain.SubstanceDefinition{}, err
+	}
+	if _, err := r.validateLocked(effective); err != nil {
+		return domain.SubstanceDefinition{}, err
+	}
+
+	if r.private == nil {
+		r.private = make(map[string]Layer)
+	}
+	r.private[userID] = layer
+
+	var id string
+	_ = json.Unmarshal(fields["id"], &id)
+	return indexDefinitions(effective)[id], nil
+}
+
+// DeleteUserDefinition drops one private entry.
+func (r *InMemoryRepo) DeleteUserDefinition(userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	layer, ok := r.private[userID]
+	if _, exists := layer.Entries[id]; !ok || !exists {
+		return fmt.Errorf("%w: %s has no private definition of %s", ErrNotFound, userID, id)
+	}
+	entries := make(map[string]map[string]json.RawMessage, len(layer.Entries))
+	for eid, e := range layer.Entries {
+		if eid != id {
+			entries[eid] = e
+		}
+	}
+	layer.Entries = entries
+	layer.order = slices.DeleteFunc(slices.Clone(layer.order), func(x string) bool { return x == id })
+	r.private[userID] = layer
+	return nil
+}
+
+// effectiveLocked lists the shared catalog in a stable order.
+func (r *InMemoryRepo) effectiveLocked() []domain.SubstanceDefinition {
+	defs := make([]domain.SubstanceDefinition, 0, len(r.data))
+	for _, id := range sortedKeys(r.data) {
+		defs = append(defs, r.data[id])
+	}
+	return defs
+}
+
+// staticRepo is a read-only snapshot of a catalog.
+type staticRepo map[string]domain.SubstanceDefinition
+
+// userView is one user's catalog, searchable by their private names and aliases too.
+type userView struct {
+	staticRepo
+	search *SearchIndex
+}
+
+func (v userView) Search(query string, limit int) []SearchHit {
+	return v.search.Search(query, limit)
+}
+
+func (v userView) Resolve(name string) (string, []SearchHit, error) {
+	return v.search.Resolve(name)
+}
+
+func (s staticRepo) GetDefinition(id string) (domain.SubstanceDefinition, error) {
+	def, ok := s[id]
+	if !ok {
+		return domain.SubstanceDefinition{}, fmt.Errorf("substance '%s' not found", id)
+	}
+	return def, nil
+}
+
+func (s staticRepo) GetAll() (map[string]domain.SubstanceDefinition, error) {
+	copyMap := make(map[string]domain.SubstanceDefinition, len(s))
+	for k, v := range s {
+		copyMap[k] = v
+	}
+	return copyMap, nil
+}
+
+// describeLayers is a one-line summary for startup logs.
+func describeLayers(layers []Layer) string {
+	names := make([]string, len(layers))
+	for i, l := range layers {
+		names[i] = fmt.Sprintf("%s (%d)", l.Name, len(l.Entries))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayersMergeFieldsAndTrackProvenance(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "substances.json")
+	writeCatalog(t, path, `[
+		{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99},
+		{"id": "iron", "name": "Iron", "category": "Mineral", "half_life_hours": 6, "bioavailability": 0.3}
+	]`)
+	overrides := filepath.Join(dir, "overrides")
+	if err := os.Mkdir(overrides, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeCatalog(t, filepath.Join(overrides, "site.json"), `[
+		{"id": "caffeine", "max_daily_mg": 300},
+		{"id": "house-blend", "name": "House Blend", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.9}
+	]`)
+
+	repo, err := NewInMemoryRepo(path, WithOverrideDir(overrides))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 1. Field-level merge: the override adds a cap, the base half-life survives
+	def, _ := repo.GetDefinition("caffeine")
+	if def.MaxDailyMg != 300 || def.HalfLifeHours != 5 {
+		t.Errorf("Expected merged caffeine, got %+v", def)
+	}
+	if _, err := repo.GetDefinition("house-blend"); err != nil {
+		t.Errorf("Expected the override-only substance to exist: %v", err)
+	}
+
+	// 2. A private definition only exists for its user
+	if _, err := repo.SetUserDefinition("alice", json.RawMessage(`{"id": "caffeine", "half_life_hours": 8}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def, _ := repo.ForUser("alice").GetDefinition("caffeine"); def.HalfLifeHours != 8 || def.MaxDailyMg != 300 {
+		t.Errorf("Expected alice's half-life on top of the site cap, got %+v", def)
+	}
+	if def, _ := repo.ForUser("bob").GetDefinition("caffeine"); def.HalfLifeHours != 5 {
+		t.Errorf("Expected bob to see the shared half-life, got %+v", def)
+	}
+
+	// 3. Provenance names the winning layer per field
+	prov, err := repo.Provenance("alice", "caffeine")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prov["name"] != LayerBase || prov["max_daily_mg"] != "override:site.json" || prov["half_life_hours"] != "user:alice" {
+		t.Errorf("Unexpected provenance: %v", prov)
+	}
+
+	// 4. A private definition that breaks the graph is refused
+	if _, err := repo.SetUserDefinition("alice", json.RawMessage(`{"id": "iron", "half_life_hours": 0}`)); err == nil {
+		t.Errorf("Expected an invalid private definition to be rejected")
+	}
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Severity ranks lint findings. Errors break the engine, warnings are suspicious data.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// LintIssue is a single problem found in the catalog.
+type LintIssue struct {
+	Severity    Severity `json:"severity"`
+	Code        string   `json:"code"` // Stable identifier (e.g., "dangling-edge")
+	SubstanceID string   `json:"substance_id"`
+	Message     string   `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("[%s] %s: %s (%s)", i.Severity, i.SubstanceID, i.Message, i.Code)
+}
+
+// HasErrors reports whether any issue is error-level.
+func HasErrors(issues []LintIssue) bool {
+	return ErrorCount(issues) > 0
+}
+
+// ErrorCount counts the error-level issues (the ones that fail strict mode).
+func ErrorCount(issues []LintIssue) int {
+	n := 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// Lint checks the catalog as a graph: node values, edge targets and edge pairs.
+// It takes the raw slice (not the map) so duplicate IDs are still visible.
+func Lint(defs []domain.SubstanceDefinition) []LintIssue {
+	var issues []LintIssue
+	add := func(sev Severity, code, id, format string, args ...any) {
+		issues = append(issues, LintIssue{Severity: sev, Code: code, SubstanceID: id, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 1. Nodes: IDs and impossible values
+	byID := make(map[string]domain.SubstanceDefinition, len(defs))
+	for _, def := range defs {
+		if def.ID == "" {
+			add(SeverityError, "missing-id", "?", "substance %q has no id", def.Name)
+			continue
+		}
+		if _, dup := byID[def.ID]; dup {
+			add(SeverityError, "duplicate-substance", def.ID, "id is defined more than once")
+		}
+		byID[def.ID] = def
+
+		// Salt forms borrow everything from their moiety (checked in step 3)
+		if def.ActiveMoietyID == "" {
+			if def.HalfLifeHours <= 0 {
+				add(SeverityError, "invalid-half-life", def.ID, "half_life_hours must be > 0, got %g", def.HalfLifeHours)
+			}
+			if def.Bioavailability < 0 || def.Bioavailability > 1 {
+				add(SeverityError, "invalid-bioavailability", def.ID, "bioavailability must be within 0-1, got %g", def.Bioavailability)
+			}
+		}
+		if !slices.Contains(domain.KnownCategories, def.Category) {
+			add(SeverityError, "unknown-category", def.ID, "unknown category %q", def.Category)
+		}
+		if def.MaxDailyMg < 0 || def.MaxSingleDoseMg < 0 {
+			add(SeverityError, "invalid-limit", def.ID, "intake limits cannot be negative")
+		}
+		if def.MinEffectiveMg < 0 || def.ToxicMg < 0 {
+			add(SeverityError, "invalid-window", def.ID, "therapeutic window amounts cannot be negative")
+		}
+		if def.ToxicMg > 0 && def.MinEffectiveMg >= def.ToxicMg {
+			add(SeverityWarning, "invalid-window", def.ID, "min_effective_mg (%g) is not below toxic_mg (%g)", def.MinEffectiveMg, def.ToxicMg)
+		}
+		for axis, weight := range def.Loads {
+			if weight < 0 {
+				add(SeverityError, "invalid-load", def.ID, "load weight for %q cannot be negative", axis)
+			}
+		}
+		if def.MaxDailyMg > 0 && def.MaxSingleDoseMg > def.MaxDailyMg {
+			add(SeverityWarning, "invalid-limit", def.ID, "max_single_dose_mg (%g) exceeds max_daily_mg (%g)", def.MaxSingleDoseMg, def.MaxDailyMg)
+		}
+		for _, conv := range def.Conversions {
+			switch {
+			case !slices.Contains(domain.KnownUnits, conv.From) || conv.From.IsMass():
+				add(SeverityError, "invalid-conversion", def.ID, "conversion from %q: 'from' must be a non-mass unit (IU, mL)", conv.From)
+			case !conv.To.IsMass():
+				add(SeverityError, "invalid-conversion", def.ID, "conversion from %s: 'to' must be mg, mcg or g, got %q", conv.From, conv.To)
+			case conv.Factor <= 0:
+				add(SeverityError, "invalid-conversion", def.ID, "conversion from %s: factor must be > 0, got %g", conv.From, conv.Factor)
+			}
+		}
+	}
+
+	// Names and aliases shared by several substances can't be resolved on ingest
+	terms := NewSearchIndex(defs).terms
+	for _, term := range slices.Sorted(maps.Keys(terms)) {
+		if ids := terms[term]; len(ids) > 1 {
+			add(SeverityWarning, "ambiguous-alias", ids[0], "%q also names %s", term, strings.Join(ids[1:], ", "))
+		}
+	}
+
+	// 2. Edges: targets, types and values
+	for _, def := range defs {
+		seen := make(map[string]bool)
+		for _, rule := range def.Interactions {
+			target := rule.Target()
+			if seen[strings.ToLower(target)] {
+				add(SeverityError, "duplicate-edge", def.ID, "more than one rule targets %q", target)
+			}
+			seen[strings.ToLower(target)] = true
+
+			switch {
+			case rule.TargetID != "" && rule.TargetClass != "":
+				add(SeverityError, "ambiguous-edge", def.ID, "rule sets both target_id %q and target_class %q", rule.TargetID, rule.TargetClass)
+			case rule.TargetClass != "":
+				if !slices.ContainsFunc(defs, func(d domain.SubstanceDefinition) bool { return d.ID != def.ID && d.InClass(rule.TargetClass) }) {
+					add(SeverityWarning, "empty-class", def.ID, "no other substance is in class %q, so the rule never fires", rule.TargetClass)
+				}
+			case rule.TargetID == "":
+				add(SeverityError, "dangling-edge", def.ID, "rule needs a target_id or target_class")
+			case rule.TargetID == def.ID:
+				add(SeverityError, "self-edge", def.ID, "rule targets itself")
+			default:
+				if _, ok := byID[rule.TargetID]; !ok {
+					add(SeverityError, "dangling-edge", def.ID, "rule targets unknown substance %q", rule.TargetID)
+				}
+			}
+			if !slices.Contains(domain.KnownInteractionTypes, rule.Type) {
+				add(SeverityError, "unknown-type", def.ID, "rule -> %s has unknown type %q", target, rule.Type)
+			}
+			if rule.Evidence != "" && !slices.Contains(domain.KnownEvidenceLevels, rule.Evidence) {
+				add(SeverityError, "unknown-evidence", def.ID, "rule -> %s has unknown evidence level %q", target, rule.Evidence)
+			}
+			if rule.WindowHours < 0 || rule.MaxWindowHours < 0 {
+				add(SeverityError, "invalid-window", def.ID, "rule -> %s has a negative window", target)
+			}
+			if rule.MinSourceMg < 0 || rule.MinTargetMg < 0 || rule.WindowScaleMg < 0 {
+				add(SeverityError, "invalid-threshold", def.ID, "rule -> %s has a negative dose threshold", target)
+			}
+		}
+	}
+
+	// 3. Metabolites and active moieties must point at real substances
+	for _, def := range defs {
+		if def.ActiveMoietyID != "" {
+			moiety, ok := byID[def.ActiveMoietyID]
+			switch {
+			case !ok || def.ActiveMoietyID == def.ID:
+				add(SeverityError, "dangling-moiety", def.ID, "active moiety %q is not a separate catalog substance", def.ActiveMoietyID)
+			case moiety.ActiveMoietyID != "":
+				add(SeverityError, "nested-moiety", def.ID, "active moiety %q is itself a salt form", def.ActiveMoietyID)
+			}
+			if def.ActiveFraction < 0 || def.ActiveFraction > 1 {
+				add(SeverityError, "invalid-fraction", def.ID, "active_fraction must be within (0, 1], got %g", def.ActiveFraction)
+			}
+			if len(def.Interactions) > 0 || len(def.Metabolites) > 0 || len(def.Contraindications) > 0 || len(def.Classes) > 0 || def.MaxDailyMg > 0 || def.MaxSingleDoseMg > 0 || def.HalfLifeHours > 0 {
+				add(SeverityWarning, "ignored-on-salt-form", def.ID, "kinetics, limits and interactions of a salt form are ignored; set them on %q", def.ActiveMoietyID)
+			}
+		}
+		for _, rule := range def.Interactions {
+			if target, ok := byID[rule.TargetID]; ok && target.ActiveMoietyID != "" {
+				add(SeverityWarning, "edge-to-salt-form", def.ID, "rule -> %s never fires; doses are tracked as %q", rule.TargetID, target.ActiveMoietyID)
+			}
+		}
+
+		for _, met := range def.Metabolites {
+			if _, ok := byID[met.SubstanceID]; !ok || met.SubstanceID == def.ID {
+				add(SeverityError, "dangling-metabolite", def.ID, "metabolite %q is not a separate catalog substance", met.SubstanceID)
+			}
+			if met.Fraction <= 0 || met.Fraction > 1 {
+				add(SeverityError, "invalid-fraction", def.ID, "metabolite %q fraction must be within (0, 1], got %g", met.SubstanceID, met.Fraction)
+			}
+		}
+	}
+
+	// 4. Contraindications need a subject and a known severity
+	for _, def := range defs {
+		for _, ci := range def.Contraindications {
+			if ci.Condition == "" && ci.Medication == "" {
+				add(SeverityError, "empty-contraindication", def.ID, "contraindication needs a condition or medication")
+			}
+			if !slices.Contains(domain.KnownInteractionTypes, ci.Type) {
+				add(SeverityError, "unknown-type", def.ID, "contraindication has unknown type %q", ci.Type)
+			}
+			if ci.Evidence != "" && !slices.Contains(domain.KnownEvidenceLevels, ci.Evidence) {
+				add(SeverityError, "unknown-evidence", def.ID, "contraindication has unknown evidence level %q", ci.Evidence)
+			}
+		}
+	}
+
+	// 5. Edge pairs: A -> B vs B -> A (reported once, from the lower ID)
+	for _, def := range defs {
+		for _, rule := range def.Interactions {
+			other, ok := byID[rule.TargetID]
+			if !ok || def.ID >= other.ID {
+				continue
+			}
+			for _, back := range other.Interactions {
+				if back.TargetID != def.ID {
+					continue
+				}
+				switch {
+				case contradicts(rule.Type, back.Type):
+					add(SeverityWarning, "contradictory-edge", def.ID, "%s -> %s is %s but %s -> %s is %s",
+						def.ID, other.ID, rule.Type, other.ID, def.ID, back.Type)
+				case rule.Type == back.Type && rule.WindowHours != back.WindowHours:
+					add(SeverityWarning, "asymmetric-window", def.ID, "%s -> %s window is %gh but %s -> %s is %gh",
+						def.ID, other.ID, rule.WindowHours, other.ID, def.ID, back.WindowHours)
+				}
+			}
+		}
+	}
+
+	// 6. Provenance: every pharmacokinetic number should cite a source
+	for _, def := range defs {
+		var unsourced []string
+		checkRefs := func(prefix string, refs map[string][]domain.Reference, params []string, known []string) {
+			for _, param := range params {
+				if len(refs[param]) == 0 {
+					unsourced = append(unsourced, prefix+param)
+				}
+			}
+			for _, param := range slices.Sorted(maps.Keys(refs)) {
+				if !slices.Contains(known, param) {
+					add(SeverityWarning, "invalid-reference", def.ID, "reference for unknown parameter %s%s", prefix, param)
+				}
+				for _, ref := range refs[param] {
+					if strings.TrimSpace(ref.Citation) == "" {
+						add(SeverityWarning, "invalid-reference", def.ID, "reference for %s%s has no citation", prefix, param)
+					}
+				}
+			}
+		}
+
+		checkRefs("", def.References, substanceParams(def), citableSubstanceParams)
+		for _, rule := range def.Interactions {
+			checkRefs("interactions["+rule.Target()+"].", rule.References, interactionParams(rule), citableInteractionParams)
+		}
+		if len(unsourced) > 0 {
+			add(SeverityWarning, "unsourced-parameter", def.ID, "no reference for %s", strings.Join(unsourced, ", "))
+		}
+	}
+
+	return issues
+}
+
+// Parameters that may carry references, by JSON field name.
+var (
+	citableSubstanceParams = []string{
+		"half_life_hours", "bioavailability", "min_effective_mg", "toxic_mg",
+		"max_daily_mg", "max_single_dose_mg", "metabolites", "contraindications", "loads", "active_fraction",
+	}
+	citableInteractionParams = []string{
+		"type", "window_hours", "min_source_mg", "min_target_mg", "window_scale_mg", "max_window_hours",
+	}
+)
+
+// substanceParams lists the parameters of 'def' that are set and so need a source.
+func substanceParams(def domain.SubstanceDefinition) []string {
+	if def.ActiveMoietyID != "" {
+		return nil // Stoichiometry, not a measurement
+	}
+	params := []string{"half_life_hours", "bioavailability"}
+	for _, p := range []struct {
+		name string
+		set  bool
+	}{
+		{"min_effective_mg", def.MinEffectiveMg > 0},
+		{"toxic_mg", def.ToxicMg > 0},
+		{"max_daily_mg", def.MaxDailyMg > 0},
+		{"max_single_dose_mg", def.MaxSingleDoseMg > 0},
+		{"metabolites", len(def.Metabolites) > 0},
+		{"contraindications", len(def.Contraindications) > 0},
+		{"loads", len(def.Loads) > 0},
+	} {
+		if p.set {
+			params = append(params, p.name)
+		}
+	}
+	return params
+}
+
+// interactionParams lists the parameters of an edge that are set and so need a source.
+func interactionParams(rule domain.Interaction) []string {
+	params := []string{"type", "window_hours"}
+	for _, p := range []struct {
+		name string
+		set  bool
+	}{
+		{"min_source_mg", rule.MinSourceMg > 0},
+		{"min_target_mg", rule.MinTargetMg > 0},
+		{"window_scale_mg", rule.WindowScaleMg > 0},
+		{"max_window_hours", rule.MaxWindowHours > 0},
+	} {
+		if p.set {
+			params = append(params, p.name)
+		}
+	}
+	return params
+}
+
+// contradicts is true when one side claims a benefit and the other a harm.
+func contradicts(a, b domain.InteractionType) bool {
+	return (a == domain.TypePotentiate) != (b == domain.TypePotentiate)
+}
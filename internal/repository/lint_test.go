@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sitanshunandan/glate/configs"
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+func TestLintFindsGraphProblems(t *testing.T) {
+	defs := []domain.SubstanceDefinition{
+		{ID: "iron", Name: "Iron", Category: domain.CatMineral, HalfLifeHours: 6, Bioavailability: 0.9, Interactions: []domain.Interaction{
+			{TargetID: "caffeine", Type: domain.TypeInhibit, WindowHours: 2},
+		}},
+		{ID: "caffeine", Name: "Caffeine", Category: domain.CatStimulant, HalfLifeHours: 5, Bioavailability: 0.99, Interactions: []domain.Interaction{
+			{TargetID: "iron", Type: domain.TypeInhibit, WindowHours: 1.5},
+			{TargetID: "ghost", Type: domain.TypeDangerous, WindowHours: 24},
+		}},
+		{ID: "caffeine", Name: "Caffeine Again", Category: "Potion", HalfLifeHours: 0, Bioavailability: 1.5},
+	}
+
+	found := make(map[string]bool)
+	for _, issue := range Lint(defs) {
+		found[issue.Code] = true
+	}
+
+	for _, code := range []string{"dangling-edge", "duplicate-substance", "asymmetric-window", "invalid-half-life", "invalid-bioavailability", "unknown-category", "unsourced-parameter"} {
+		if !found[code] {
+			t.Errorf("Expected a %q issue", code)
+		}
+	}
+}
+
+func TestLintCleanCatalog(t *testing.T) {
+	ref := []domain.Reference{{Citation: "Test fixture"}}
+	kinetics := map[string][]domain.Reference{"half_life_hours": ref, "bioavailability": ref}
+	defs := []domain.SubstanceDefinition{
+		{ID: "vitamin-c", Category: domain.CatVitamin, HalfLifeHours: 2, Bioavailability: 1, References: kinetics, Interactions: []domain.Interaction{
+			{TargetID: "iron", Type: domain.TypePotentiate, WindowHours: 0.5, References: map[string][]domain.Reference{"type": ref, "window_hours": ref}},
+		}},
+		{ID: "iron", Category: domain.CatMineral, HalfLifeHours: 6, Bioavailability: 0.9, References: kinetics},
+	}
+
+	if issues := Lint(defs); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestLintReferences(t *testing.T) {
+	defs := []domain.SubstanceDefinition{
+		{ID: "iron", Category: domain.CatMineral, HalfLifeHours: 6, Bioavailability: 0.9, References: map[string][]domain.Reference{
+			"half_life_hours": {{Citation: "Fixture", SourceID: "pmid:1"}},
+			"bioavailability": {{SourceID: "pmid:2"}},  // no citation
+			"color":           {{Citation: "Fixture"}}, // not a parameter
+		}},
+	}
+
+	var invalid int
+	for _, issue := range Lint(defs) {
+		switch issue.Code {
+		case "invalid-reference":
+			invalid++
+		case "unsourced-parameter":
+			t.Errorf("Expected every parameter to count as sourced, got %v", issue)
+		}
+	}
+	if invalid != 2 {
+		t.Errorf("Expected 2 invalid-reference issues, got %d", invalid)
+	}
+}
+
+func TestLintFlagsUnsourcedLoads(t *testing.T) {
+	defs := []domain.SubstanceDefinition{
+		{ID: "caffeine", Category: domain.CatStimulant, HalfLifeHours: 5, Bioavailability: 0.99, Loads: map[string]float64{"stimulant": 1}, References: map[string][]domain.Reference{
+			"half_life_hours": {{Citation: "Fixture"}},
+			"bioavailability": {{Citation: "Fixture"}},
+		}},
+	}
+
+	issues := Lint(defs)
+	if len(issues) != 1 || issues[0].Code != "unsourced-parameter" || !strings.Contains(issues[0].Message, "loads") {
+		t.Errorf("Expected loads to be flagged as unsourced, got %v", issues)
+	}
+}
+
+func TestLintClassEdges(t *testing.T) {
+	defs := []domain.SubstanceDefinition{
+		{ID: "sertraline", Name: "Sertraline", Category: domain.CatNootropic, HalfLifeHours: 26, Bioavailability: 0.44, Classes: []string{"ssri"}},
+		{ID: "dxm", Name: "DXM", Category: domain.CatNootropic, HalfLifeHours: 4, Bioavailability: 0.11, Interactions: []domain.Interaction{
+			{TargetClass: "SSRI", Type: domain.TypeDangerous, WindowHours: 24},
+			{TargetClass: "maoi", Type: domain.TypeDangerous, WindowHours: 24},
+			{TargetID: "sertraline", TargetClass: "ssri", Type: domain.TypeDangerous, WindowHours: 24},
+		}},
+	}
+
+	codes := make(map[string]string)
+	for _, issue := range Lint(defs) {
+		if issue.Code != "unsourced-parameter" {
+			codes[issue.Code] = issue.Message
+		}
+	}
+	if len(codes) != 3 || codes["empty-class"] == "" || codes["ambiguous-edge"] == "" || codes["duplicate-edge"] == "" {
+		t.Errorf("Expected empty-class, ambiguous-edge and duplicate-edge only, got %v", codes)
+	}
+	if !strings.Contains(codes["empty-class"], `"maoi"`) {
+		t.Errorf("Expected the empty class to be named, got %q", codes["empty-class"])
+	}
+}
+
+func TestShippedCatalogHasNoErrors(t *testing.T) {
+	raw, err := configs.Default(configs.Substances)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defs, err := ParseDefinitions(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Strict mode must be usable out of the box
+	issues := Lint(defs)
+	if n := ErrorCount(issues); n > 0 {
+		t.Errorf("Expected the shipped catalog to lint without errors, got %d: %v", n, issues)
+	}
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/sitanshunandan/glate/internal/domain"
 )
@@ -18,36 +20,169 @@ type Repository interface {
 
 // InMemoryRepo is a thread-safe storage implementation.
 type InMemoryRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.SubstanceDefinition
+	mu         sync.RWMutex
+	data       map[string]domain.SubstanceDefinition
+	validation ValidationMode
+
+	path     string      // Source file, for reloads; empty means 'embedded'
+	embedded []byte      // Catalog used when there is no source file
+	modTime  time.Time   // Source file mtime at the last (re)load
+	issues   []LintIssue // Lint findings of the loaded catalog
+	search   *SearchIndex
+
+	// Layers: 'data' is the base file with the overrides merged on top
+	base        map[string]domain.SubstanceDefinition // The base file alone, which is what writes edit
+	order       []string                              // Base IDs in file order, so writes keep the file stable
+	overrideDir string
+	overrides   []Layer
+	provenance  map[string]Provenance
+	private     map[string]Layer // User ID -> private definitions
+
+	version int              // Bumped on every change to the catalog
+	history []CatalogVersion // One entry per version, oldest first
+}
+
+// ValidationMode controls what NewInMemoryRepo does with catalog lint findings.
+type ValidationMode int
+
+const (
+	ValidateOff    ValidationMode = iota // Load whatever is in the file
+	ValidateWarn                         // Log every finding, load anyway
+	ValidateStrict                       // Log every finding, refuse to load on errors
+)
+
+// Option configures an InMemoryRepo.
+type Option func(*InMemoryRepo)
+
+// WithValidation runs the catalog linter at load time.
+func WithValidation(mode ValidationMode) Option {
+	return func(r *InMemoryRepo) {
+		r.validation = mode
+	}
+}
+
+// WithEmbedded supplies the catalog to use when NewInMemoryRepo gets no file path
+// (e.g., the defaults compiled into the binary). Writes to it stay in memory.
+func WithEmbedded(raw []byte) Option {
+	return func(r *InMemoryRepo) {
+		r.embedded = raw
+	}
 }
 
-// NewInMemoryRepo initializes the repo by loading data from a JSON file.
-func NewInMemoryRepo(filePath string) (*InMemoryRepo, error) {
-	// 1. Open the file
-	file, err := os.Open(filePath)
+// NewInMemoryRepo initializes the repo by loading data from a JSON file,
+// or from the embedded catalog when 'filePath' is empty.
+func NewInMemoryRepo(filePath string, opts ...Option) (*InMemoryRepo, error) {
+	repo := &InMemoryRepo{}
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	// 1. Load the base file and the override layers on top of it
+	repo.path = filePath
+	definitions, err := repo.loadBase()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := LoadOverrideDir(repo.overrideDir)
+	if err != nil {
+		return nil, err
+	}
+	effective, provenance, err := composeCatalog(definitions, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) > 0 {
+		log.Printf("📚 Catalog layers: base + %s", describeLayers(overrides))
+	}
+
+	// 2. Validate the merged graph before anyone can query it
+	issues := Lint(effective)
+	if repo.validation != ValidateOff {
+		for _, issue := range issues {
+			log.Printf("⚠️  Catalog %s", issue)
+		}
+		if repo.validation == ValidateStrict && HasErrors(issues) {
+			return nil, fmt.Errorf("catalog %s failed validation with %d error(s)", repo.source(), ErrorCount(issues))
+		}
+	}
+
+	// 3. Convert slice to map for O(1) lookups
+	repo.modTime = sourcesModTime(filePath, repo.overrideDir)
+	repo.installLocked(definitions, overrides, effective, provenance, issues)
+	repo.recordLocked("load", "", CatalogDiff{Added: orderOf(effective)})
+	return repo, nil
+}
+
+// indexDefinitions converts the slice to a map (last definition wins on duplicates).
+func indexDefinitions(definitions []domain.SubstanceDefinition) map[string]domain.SubstanceDefinition {
+	dataMap := make(map[string]domain.SubstanceDefinition)
+	for _, def := range definitions {
+		dataMap[def.ID] = def
+	}
+	return dataMap
+}
+
+// orderOf lists the unique IDs in file order.
+func orderOf(definitions []domain.SubstanceDefinition) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, def := range definitions {
+		if !seen[def.ID] {
+			seen[def.ID] = true
+			ids = append(ids, def.ID)
+		}
+	}
+	return ids
+}
+
+// LoadDefinitions decodes a catalog file without indexing it.
+// Duplicates are preserved so the linter can see them.
+func LoadDefinitions(filePath string) ([]domain.SubstanceDefinition, error) {
+	// 1. Read the file
+	raw, err := os.ReadFile(filePath)
 	if err != nil {
 		// Try absolute path if relative fails (common issue in Go tests/run)
 		absPath, _ := filepath.Abs(filePath)
 		return nil, fmt.Errorf("failed to open file at %s: %w", absPath, err)
 	}
-	defer file.Close()
 
-	// 2. Decode JSON into a temporary slice
+	// 2. Decode JSON into a slice
+	return ParseDefinitions(raw)
+}
+
+// ParseDefinitions decodes a catalog (e.g., the embedded defaults) without indexing it.
+func ParseDefinitions(raw []byte) ([]domain.SubstanceDefinition, error) {
 	var definitions []domain.SubstanceDefinition
-	if err := json.NewDecoder(file).Decode(&definitions); err != nil {
+	if err := json.Unmarshal(raw, &definitions); err != nil {
 		return nil, fmt.Errorf("invalid JSON format: %w", err)
 	}
+	return definitions, nil
+}
 
-	// 3. Convert slice to map for O(1) lookups
-	dataMap := make(map[string]domain.SubstanceDefinition)
-	for _, def := range definitions {
-		dataMap[def.ID] = def
+// loadBase reads the base catalog from the source file, or the embedded one.
+func (r *InMemoryRepo) loadBase() ([]domain.SubstanceDefinition, error) {
+	if r.path == "" {
+		if r.embedded == nil {
+			return nil, fmt.Errorf("no catalog: need a file path or an embedded catalog")
+		}
+		return ParseDefinitions(r.embedded)
 	}
+	return LoadDefinitions(r.path)
+}
+
+// source names the base catalog for logs and errors.
+func (r *InMemoryRepo) source() string {
+	if r.path == "" {
+		return "(embedded)"
+	}
+	return r.path
+}
 
-	return &InMemoryRepo{
-		data: dataMap,
-	}, nil
+// Definitions lists the effective catalog (base + overrides), sorted by ID.
+func (r *InMemoryRepo) Definitions() []domain.SubstanceDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.effectiveLocked()
 }
 
 // GetDefinition returns a specific substance by ID (Concurrent-safe read).
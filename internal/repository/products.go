@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ProductCatalog is a read-only index of products (e.g., configs/products.json).
+type ProductCatalog struct {
+	data  map[string]domain.Product
+	order []string
+}
+
+// NewProductCatalog indexes the products (last definition wins on duplicates).
+func NewProductCatalog(products []domain.Product) *ProductCatalog {
+	c := &ProductCatalog{data: make(map[string]domain.Product)}
+	for _, p := range products {
+		if _, exists := c.data[p.ID]; !exists {
+			c.order = append(c.order, p.ID)
+		}
+		c.data[p.ID] = p
+	}
+	return c
+}
+
+// LoadProducts reads a product file without indexing it.
+func LoadProducts(filePath string) ([]domain.Product, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open products at %s: %w", filePath, err)
+	}
+	return ParseProducts(raw)
+}
+
+// ParseProducts decodes a product list (e.g., the embedded defaults).
+func ParseProducts(raw []byte) ([]domain.Product, error) {
+	var products []domain.Product
+	if err := json.Unmarshal(raw, &products); err != nil {
+		return nil, fmt.Errorf("invalid JSON format: %w", err)
+	}
+	return products, nil
+}
+
+// GetProduct looks a product up by ID. A nil catalog has no products.
+func (c *ProductCatalog) GetProduct(id string) (domain.Product, error) {
+	if c != nil {
+		if p, ok := c.data[id]; ok {
+			return p, nil
+		}
+	}
+	return domain.Product{}, fmt.Errorf("product '%s' not found", id)
+}
+
+// All lists every product in file order.
+func (c *ProductCatalog) All() []domain.Product {
+	if c == nil {
+		return nil
+	}
+	products := make([]domain.Product, 0, len(c.order))
+	for _, id := range c.order {
+		products = append(products, c.data[id])
+	}
+	return products
+}
+
+// LintProducts checks products against the substance catalog.
+func LintProducts(products []domain.Product, substances map[string]domain.SubstanceDefinition) []LintIssue {
+	var issues []LintIssue
+	add := func(sev Severity, code, id, format string, args ...any) {
+		issues = append(issues, LintIssue{Severity: sev, Code: code, SubstanceID: id, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var seen []string
+	for _, p := range products {
+		if p.ID == "" {
+			add(SeverityError, "missing-id", "?", "product %q has no id", p.Name)
+			continue
+		}
+		if slices.Contains(seen, p.ID) {
+			add(SeverityError, "duplicate-product", p.ID, "product id is defined more than once")
+		}
+		seen = append(seen, p.ID)
+
+		if len(p.Components) == 0 {
+			add(SeverityError, "empty-product", p.ID, "product has no components")
+		}
+		var components []string
+		for _, c := range p.Components {
+			if _, ok := substances[c.SubstanceID]; !ok {
+				add(SeverityError, "dangling-component", p.ID, "component %q is not a catalog substance", c.SubstanceID)
+			}
+			if c.AmountMg <= 0 {
+				add(SeverityError, "invalid-amount", p.ID, "component %q amount must be > 0, got %g", c.SubstanceID, c.AmountMg)
+			}
+			if slices.Contains(components, c.SubstanceID) {
+				add(SeverityWarning, "duplicate-component", p.ID, "component %q is listed more than once", c.SubstanceID)
+			}
+			components = append(components, c.SubstanceID)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+func TestProductCatalogAndLint(t *testing.T) {
+	products := []domain.Product{
+		{ID: "coffee-cup", Components: []domain.ProductComponent{{SubstanceID: "caffeine", AmountMg: 95}}},
+		{ID: "bad", Components: []domain.ProductComponent{
+			{SubstanceID: "unobtainium", AmountMg: 10},
+			{SubstanceID: "caffeine", AmountMg: 0},
+			{SubstanceID: "caffeine", AmountMg: 50},
+		}},
+		{ID: "coffee-cup", Components: []domain.ProductComponent{{SubstanceID: "caffeine", AmountMg: 120}}},
+	}
+
+	// Last definition wins, first position is kept
+	catalog := NewProductCatalog(products)
+	if all := catalog.All(); len(all) != 2 || all[0].ID != "coffee-cup" {
+		t.Errorf("Expected coffee-cup then bad, got %+v", all)
+	}
+	if p, err := catalog.GetProduct("coffee-cup"); err != nil || p.Components[0].AmountMg != 120 {
+		t.Errorf("Expected the later coffee-cup, got %+v (%v)", p, err)
+	}
+	var none *ProductCatalog
+	if _, err := none.GetProduct("coffee-cup"); err == nil {
+		t.Error("Expected a nil catalog to have no products")
+	}
+
+	substances := map[string]domain.SubstanceDefinition{"caffeine": {ID: "caffeine"}}
+	codes := make(map[string]int)
+	for _, issue := range LintProducts(products, substances) {
+		codes[issue.Code]++
+	}
+	for _, code := range []string{"duplicate-product", "dangling-component", "invalid-amount", "duplicate-component"} {
+		if codes[code] != 1 {
+			t.Errorf("Expected one %s finding, got %d (%v)", code, codes[code], codes)
+		}
+	}
+}
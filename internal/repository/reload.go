@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Reloader is implemented by repositories that can re-read their source.
+type Reloader interface {
+	Reload() (CatalogDiff, error)
+}
+
+// CatalogDiff summarizes what a reload changed.
+type CatalogDiff struct {
+	Added   []string            `json:"added,omitempty"`
+	Removed []string            `json:"removed,omitempty"`
+	Changed map[string][]string `json:"changed,omitempty"` // Substance ID -> changed JSON fields
+}
+
+// Empty is true when the reload changed nothing.
+func (d CatalogDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d CatalogDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	parts := []string{fmt.Sprintf("+%d added, -%d removed, ~%d changed", len(d.Added), len(d.Removed), len(d.Changed))}
+	for _, id := range d.Added {
+		parts = append(parts, "+ "+id)
+	}
+	for _, id := range d.Removed {
+		parts = append(parts, "- "+id)
+	}
+	for _, id := range sortedKeys(d.Changed) {
+		parts = append(parts, fmt.Sprintf("~ %s (%s)", id, strings.Join(d.Changed[id], ", ")))
+	}
+	return strings.Join(parts, "\n   ")
+}
+
+// Reload re-reads the source file and override layers, validates the merged
+// catalog and swaps it in atomically. The current catalog stays in place if a
+// file is unreadable or the new version adds error-level lint findings
+// (in strict mode: has any at all).
+func (r *InMemoryRepo) Reload() (CatalogDiff, error) {
+	definitions, err := r.loadBase()
+	if err != nil {
+		return CatalogDiff{}, err
+	}
+	overrides, err := LoadOverrideDir(r.overrideDir)
+	if err != nil {
+		return CatalogDiff{}, err
+	}
+	effective, provenance, err := composeCatalog(definitions, overrides)
+	if err != nil {
+		return CatalogDiff{}, err
+	}
+	modTime := sourcesModTime(r.path, r.overrideDir)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	issues, err := r.validateLocked(effective)
+	if err != nil {
+		return CatalogDiff{}, err
+	}
+
+	diff := r.installLocked(definitions, overrides, effective, provenance, issues)
+	r.modTime = modTime
+	if !diff.Empty() {
+		r.recordLocked("reload", "", diff)
+	}
+	return diff, nil
+}
+
+// installLocked swaps in a validated catalog and rebuilds everything derived from it.
+func (r *InMemoryRepo) installLocked(base []domain.SubstanceDefinition, overrides []Layer, effective []domain.SubstanceDefinition, provenance map[string]Provenance, issues []LintIssue) CatalogDiff {
+	next := indexDefinitions(effective)
+	diff := diffCatalogs(r.data, next)
+	r.base = indexDefinitions(base)
+	r.order = orderOf(base)
+	r.overrides = overrides
+	r.provenance = provenance
+	r.data = next
+	r.search = NewSearchIndex(effective)
+	r.issues = issues
+	return diff
+}
+
+// sourcesModTime is the newest mtime among the base file, the override
+// directory (which changes when files are added or removed) and its files.
+func sourcesModTime(path, overrideDir string) time.Time {
+	var latest time.Time
+	var paths []string
+	if path != "" {
+		paths = append(paths, path) // The embedded catalog never changes
+	}
+	if overrideDir != "" {
+		files, _ := filepath.Glob(filepath.Join(overrideDir, "*.json"))
+		paths = append(append(paths, overrideDir), files...)
+	}
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// validateLocked lints a candidate catalog. Findings the current catalog already
+// has are tolerated, so a known defect doesn't block unrelated fixes.
+func (r *InMemoryRepo) validateLocked(definitions []domain.SubstanceDefinition) ([]LintIssue, error) {
+	issues := Lint(definitions)
+	if r.validation == ValidateStrict && HasErrors(issues) {
+		return nil, fmt.Errorf("catalog rejected: %d error(s) in strict mode", ErrorCount(issues))
+	}
+
+	var introduced []string
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && !slices.Contains(r.issues, issue) {
+			introduced = append(introduced, issue.String())
+		}
+	}
+	if len(introduced) > 0 {
+		return nil, fmt.Errorf("catalog rejected: %s", strings.Join(introduced, "; "))
+	}
+	return issues, nil
+}
+
+// Watch polls the source file and reloads it whenever its mtime changes.
+// It returns immediately; close 'stop' to end the loop.
+func (r *InMemoryRepo) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+
+	// Remember the last mtime we tried, so a rejected file isn't retried every tick
+	r.mu.RLock()
+	lastSeen := r.modTime
+	r.mu.RUnlock()
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				modTime, changed := r.sourcesChanged(lastSeen)
+				lastSeen = modTime
+				if changed {
+					LogReload(r.Reload())
+				}
+			}
+		}
+	}()
+}
+
+// sourcesChanged returns the sources' current mtime, and whether it is an
+// external edit: new since 'lastSeen' and not our own last load or write.
+func (r *InMemoryRepo) sourcesChanged(lastSeen time.Time) (time.Time, bool) {
+	modTime := sourcesModTime(r.path, r.overrideDir)
+	if modTime.IsZero() {
+		return lastSeen, false
+	}
+
+	r.mu.RLock()
+	own := r.modTime
+	r.mu.RUnlock()
+	return modTime, !modTime.Equal(lastSeen) && !modTime.Equal(own)
+}
+
+// LogReload reports the outcome of a reload in the server log.
+func LogReload(diff CatalogDiff, err error) {
+	if err != nil {
+		log.Printf("❌ Catalog reload failed, keeping the current version: %v", err)
+		return
+	}
+	log.Printf("🔄 Catalog reloaded: %s", diff)
+}
+
+// diffCatalogs compares two catalogs field by field (by JSON name).
+func diffCatalogs(old, next map[string]domain.SubstanceDefinition) CatalogDiff {
+	diff := CatalogDiff{Changed: make(map[string][]string)}
+
+	for _, id := range sortedKeys(next) {
+		prev, ok := old[id]
+		if !ok {
+			diff.Added = append(diff.Added, id)
+			continue
+		}
+		if fields := changedFields(prev, next[id]); len(fields) > 0 {
+			diff.Changed[id] = fields
+		}
+	}
+	for _, id := range sortedKeys(old) {
+		if _, ok := next[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	if len(diff.Changed) == 0 {
+		diff.Changed = nil
+	}
+	return diff
+}
+
+// changedFields lists the JSON fields that differ between two definitions.
+func changedFields(a, b domain.SubstanceDefinition) []string {
+	fieldsA, fieldsB := jsonFields(a), jsonFields(b)
+
+	var changed []string
+	for _, name := range sortedKeys(fieldsA) {
+		if !reflect.DeepEqual(fieldsA[name], fieldsB[name]) {
+			changed = append(changed, name)
+		}
+	}
+	for _, name := range sortedKeys(fieldsB) {
+		if _, ok := fieldsA[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// jsonFields decodes a definition into its generic JSON form.
+func jsonFields(def domain.SubstanceDefinition) map[string]any {
+	raw, _ := json.Marshal(def)
+	var fields map[string]any
+	json.Unmarshal(raw, &fields)
+	return fields
+}
+
+// sortedKeys returns map keys in a stable order for logs and diffs.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+func writeCatalog(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write catalog: %v", err)
+	}
+}
+
+func TestReloadSwapsAndRejects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "substances.json")
+	writeCatalog(t, path, `[{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99}]`)
+
+	repo, err := NewInMemoryRepo(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 1. A valid edit is swapped in and diffed
+	writeCatalog(t, path, `[
+		{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 6, "bioavailability": 0.99},
+		{"id": "nac", "name": "NAC", "category": "AminoAcid", "half_life_hours": 5.6, "bioavailability": 0.1}
+	]`)
+	diff, err := repo.Reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "nac" {
+		t.Errorf("Expected nac to be added, got %+v", diff)
+	}
+	if fields := diff.Changed["caffeine"]; len(fields) != 1 || fields[0] != "half_life_hours" {
+		t.Errorf("Expected caffeine half_life_hours to change, got %+v", diff.Changed)
+	}
+
+	// 2. A broken edit is rejected and the previous version is kept
+	writeCatalog(t, path, `[{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": -1, "bioavailability": 0.99}]`)
+	if _, err := repo.Reload(); err == nil {
+		t.Fatalf("Expected the invalid catalog to be rejected")
+	}
+	def, err := repo.GetDefinition("nac")
+	if err != nil || def.HalfLifeHours != 5.6 {
+		t.Errorf("Expected the previous catalog to stay in place, got %+v (%v)", def, err)
+	}
+}
+
+func TestEmbeddedCatalogNeedsNoFile(t *testing.T) {
+	embedded := []byte(`[{"id": "caffeine", "name": "Caffeine", "category": "Stimulant", "half_life_hours": 5, "bioavailability": 0.99}]`)
+	dir := t.TempDir()
+	writeCatalog(t, filepath.Join(dir, "local.json"), `[{"id": "caffeine", "half_life_hours": 6}]`)
+
+	repo, err := NewInMemoryRepo("", WithEmbedded(embedded), WithOverrideDir(dir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defs := repo.Definitions()
+	if len(defs) != 1 || defs[0].HalfLifeHours != 6 {
+		t.Errorf("Expected the override on top of the embedded catalog, got %+v", defs)
+	}
+
+	// There is nowhere to persist writes, so they are refused rather than lost on reload
+	if _, err := repo.Create(domain.SubstanceDefinition{ID: "nac", Name: "NAC", Category: "AminoAcid", HalfLifeHours: 5.6, Bioavailability: 0.1}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if version := repo.Version(); version != 1 {
+		t.Errorf("Expected a refused write to keep version 1, got %d", version)
+	}
+
+	if _, err := NewInMemoryRepo(""); err == nil {
+		t.Error("Expected an error without a file or an embedded catalog")
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// LoadTimeRules reads the catalog's time-of-day rules (e.g., configs/time_rules.json).
+func LoadTimeRules(filePath string) ([]domain.TimeRule, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open time rules at %s: %w", filePath, err)
+	}
+	return ParseTimeRules(raw)
+}
+
+// ParseTimeRules decodes time-of-day rules (e.g., the embedded defaults).
+func ParseTimeRules(raw []byte) ([]domain.TimeRule, error) {
+	var rules []domain.TimeRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("invalid JSON format: %w", err)
+	}
+	return rules, nil
+}
+
+// LoadAxes reads the catalog's load axis thresholds (e.g., configs/load_axes.json).
+func LoadAxes(filePath string) ([]domain.LoadAxis, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open load axes at %s: %w", filePath, err)
+	}
+	return ParseAxes(raw)
+}
+
+// ParseAxes decodes load axis thresholds (e.g., the embedded defaults).
+func ParseAxes(raw []byte) ([]domain.LoadAxis, error) {
+	var axes []domain.LoadAxis
+	if err := json.Unmarshal(raw, &axes); err != nil {
+		return nil, fmt.Errorf("invalid JSON format: %w", err)
+	}
+	return axes, nil
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// Searcher is implemented by repositories that can look substances up by name.
+type Searcher interface {
+	Search(query string, limit int) []SearchHit
+	Resolve(name string) (string, []SearchHit, error)
+}
+
+// MatchKind says how a search hit matched, best first.
+type MatchKind int
+
+const (
+	MatchExact  MatchKind = iota // Query equals an ID, name or alias
+	MatchPrefix                  // Query starts a term (or a word in it)
+	MatchFuzzy                   // Query is a typo or two away from a term
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case MatchExact:
+		return "exact"
+	case MatchPrefix:
+		return "prefix"
+	default:
+		return "fuzzy"
+	}
+}
+
+func (k MatchKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// SearchHit is one substance matching a query.
+type SearchHit struct {
+	SubstanceID string    `json:"substance_id"`
+	Name        string    `json:"name"`
+	MatchedTerm string    `json:"matched_term"` // The ID, name or alias that matched
+	Kind        MatchKind `json:"kind"`
+	Distance    int       `json:"distance,omitempty"` // Edit distance for fuzzy hits
+}
+
+// ErrAmbiguous is returned by Resolve when a name fits several substances.
+var ErrAmbiguous = fmt.Errorf("ambiguous substance name")
+
+// SearchIndex maps normalized IDs, names and aliases to substance IDs.
+// It is rebuilt whenever the catalog changes and never mutated afterwards.
+type SearchIndex struct {
+	terms map[string][]string // normalized term -> substance IDs
+	names map[string]string   // substance ID -> display name
+}
+
+// NewSearchIndex indexes every ID, name and alias of the catalog.
+func NewSearchIndex(definitions []domain.SubstanceDefinition) *SearchIndex {
+	idx := &SearchIndex{
+		terms: make(map[string][]string),
+		names: make(map[string]string),
+	}
+	for _, def := range definitions {
+		idx.names[def.ID] = def.Name
+		for _, term := range append([]string{def.ID, def.Name}, def.Aliases...) {
+			key := normalize(term)
+			if key != "" && !slices.Contains(idx.terms[key], def.ID) {
+				idx.terms[key] = append(idx.terms[key], def.ID)
+			}
+		}
+	}
+	return idx
+}
+
+// Search returns the best hit per substance, exact before prefix before fuzzy.
+// 'limit' <= 0 means no limit.
+func (idx *SearchIndex) Search(query string, limit int) []SearchHit {
+	q := normalize(query)
+	if q == "" {
+		return nil
+	}
+
+	// 1. Score every term, keeping the best match per substance
+	best := make(map[string]SearchHit)
+	for term, ids := range idx.terms {
+		kind, dist, ok := matchTerm(q, term)
+		if !ok {
+			continue
+		}
+		for _, id := range ids {
+			hit := SearchHit{SubstanceID: id, Name: idx.names[id], MatchedTerm: term, Kind: kind, Distance: dist}
+			if prev, seen := best[id]; !seen || hitLess(hit, prev) {
+				best[id] = hit
+			}
+		}
+	}
+
+	// 2. Rank
+	hits := make([]SearchHit, 0, len(best))
+	for _, hit := range best {
+		hits = append(hits, hit)
+	}
+	slices.SortFunc(hits, func(a, b SearchHit) int {
+		if hitLess(a, b) {
+			return -1
+		}
+		if hitLess(b, a) {
+			return 1
+		}
+		return 0
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Resolve turns a user-typed name into a substance ID. Only exact matches on
+// an ID, name or alias resolve; typos and prefixes come back as candidates,
+// because guessing the wrong compound is worse than asking.
+func (idx *SearchIndex) Resolve(name string) (string, []SearchHit, error) {
+	ids := idx.terms[normalize(name)]
+	switch len(ids) {
+	case 1:
+		return ids[0], nil, nil
+	case 0:
+		return "", idx.Search(name, 5), fmt.Errorf("%w: %q", ErrNotFound, name)
+	default:
+		var candidates []SearchHit
+		for _, id := range ids {
+			candidates = append(candidates, SearchHit{SubstanceID: id, Name: idx.names[id], MatchedTerm: normalize(name), Kind: MatchExact})
+		}
+		return "", candidates, fmt.Errorf("%w: %q matches %s", ErrAmbiguous, name, strings.Join(ids, ", "))
+	}
+}
+
+// Search looks substances up by ID, name or alias.
+func (r *InMemoryRepo) Search(query string, limit int) []SearchHit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.search.Search(query, limit)
+}
+
+// Resolve turns a user-typed name into a substance ID. See SearchIndex.Resolve.
+func (r *InMemoryRepo) Resolve(name string) (string, []SearchHit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.search.Resolve(name)
+}
+
+func hitLess(a, b SearchHit) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	return a.SubstanceID < b.SubstanceID
+}
+
+// matchTerm checks one normalized query against one normalized term.
+func matchTerm(q, term string) (MatchKind, int, bool) {
+	if q == term {
+		return MatchExact, 0, true
+	}
+	if strings.HasPrefix(term, q) {
+		return MatchPrefix, 0, true
+	}
+	for _, word := range strings.Fields(term) {
+		if strings.HasPrefix(word, q) {
+			return MatchPrefix, 0, true
+		}
+	}
+
+	// Typo tolerance: one edit for short queries, two for longer ones.
+	// Very short queries would match everything, so they only get prefixes.
+	budget := 1
+	if len(q) < 3 {
+		return 0, 0, false
+	} else if len(q) > 5 {
+		budget = 2
+	}
+	if d := editDistance(q, term); d <= budget {
+		return MatchFuzzy, d, true
+	}
+	for _, word := range strings.Fields(term) {
+		if d := editDistance(q, word); d <= budget {
+			return MatchFuzzy, d, true
+		}
+	}
+	return 0, 0, false
+}
+
+// normalize lowercases and turns punctuation into single spaces,
+// so "Vit. C", "vit-c" and "vit c" are the same term.
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// editDistance is the optimal-string-alignment distance: insertions,
+// deletions, substitutions and adjacent transpositions each cost 1.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range rb {
+		d[0][j+1] = j + 1
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+func TestSearchAndResolve(t *testing.T) {
+	idx := NewSearchIndex([]domain.SubstanceDefinition{
+		{ID: "vitamin-c", Name: "Vitamin C", Aliases: []string{"ascorbic acid", "vit c"}},
+		{ID: "caffeine", Name: "Caffeine", Aliases: []string{"coffee"}},
+		{ID: "magnesium-glycinate", Name: "Magnesium Glycinate", Aliases: []string{"magnesium"}},
+		{ID: "magnesium-citrate", Name: "Magnesium Citrate", Aliases: []string{"magnesium"}},
+	})
+
+	// 1. Aliases resolve regardless of case and punctuation
+	for _, name := range []string{"Vit. C", "ASCORBIC ACID", "coffee", "caffeine"} {
+		if _, _, err := idx.Resolve(name); err != nil {
+			t.Errorf("Expected %q to resolve, got %v", name, err)
+		}
+	}
+
+	// 2. A shared alias is ambiguous and lists both
+	if _, candidates, err := idx.Resolve("magnesium"); !errors.Is(err, ErrAmbiguous) || len(candidates) != 2 {
+		t.Errorf("Expected an ambiguous match with 2 candidates, got %v (%v)", candidates, err)
+	}
+
+	// 3. Typos don't resolve, but come back as candidates
+	_, candidates, err := idx.Resolve("cafeine")
+	if !errors.Is(err, ErrNotFound) || len(candidates) == 0 || candidates[0].SubstanceID != "caffeine" {
+		t.Errorf("Expected caffeine as a fuzzy candidate, got %v (%v)", candidates, err)
+	}
+
+	// 4. Prefixes rank before typos
+	hits := idx.Search("asc", 0)
+	if len(hits) != 1 || hits[0].SubstanceID != "vitamin-c" || hits[0].Kind != MatchPrefix {
+		t.Errorf("Expected a prefix hit on vitamin-c, got %+v", hits)
+	}
+}
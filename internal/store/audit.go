@@ -0,0 +1,40 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// AuditLog is an append-only record of overridden safety blocks.
+type AuditLog struct {
+	mu      sync.RWMutex
+	entries []domain.AuditEntry
+}
+
+// NewAuditLog initializes the storage.
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+// Record appends an entry. Entries are never edited or removed.
+func (l *AuditLog) Record(entry domain.AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// GetEntries returns a copy of the user's entries, oldest first.
+func (l *AuditLog) GetEntries(userID string) []domain.AuditEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := []domain.AuditEntry{}
+	for _, entry := range l.entries {
+		if entry.UserID == userID {
+			entry.Conflicts = append([]string(nil), entry.Conflicts...)
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/sitanshunandan/glate/internal/domain"
+)
+
+// ProfileStore keeps user settings (timezone, personal rules) in memory.
+type ProfileStore struct {
+	mu       sync.RWMutex
+	profiles map[string]domain.UserProfile
+}
+
+// NewProfileStore initializes the storage.
+func NewProfileStore() *ProfileStore {
+	return &ProfileStore{
+		profiles: make(map[string]domain.UserProfile),
+	}
+}
+
+// SetProfile replaces the user's profile.
+func (s *ProfileStore) SetProfile(profile domain.UserProfile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[profile.UserID] = cloneProfile(profile)
+}
+
+// GetProfile returns the user's profile, or an empty one with just the ID.
+func (s *ProfileStore) GetProfile(userID string) domain.UserProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[userID]
+	if !ok {
+		return domain.UserProfile{UserID: userID}
+	}
+	return cloneProfile(profile)
+}
+
+// cloneProfile deep copies the slices so callers can't mutate stored state.
+func cloneProfile(p domain.UserProfile) domain.UserProfile {
+	p.TimeRules = append([]domain.TimeRule(nil), p.TimeRules...)
+	p.Conditions = append([]string(nil), p.Conditions...)
+	p.Medications = append([]string(nil), p.Medications...)
+	if p.HalfLifeOverrides != nil {
+		overrides := make(map[string]float64, len(p.HalfLifeOverrides))
+		for id, hours := range p.HalfLifeOverrides {
+			overrides[id] = hours
+		}
+		p.HalfLifeOverrides = overrides
+	}
+	if p.PreferredUnits != nil {
+		units := make(map[string]domain.Unit, len(p.PreferredUnits))
+		for id, unit := range p.PreferredUnits {
+			units[id] = unit
+		}
+		p.PreferredUnits = units
+	}
+	return p
+}
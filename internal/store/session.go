@@ -2,6 +2,7 @@ package store
 
 import (
 	"sync"
+	"time"
 
 	"github.com/sitanshunandan/glate/internal/domain"
 )
@@ -49,6 +50,21 @@ func (s *SessionStore) GetStack(userID string) []domain.ActiveDose {
 	return copyStack
 }
 
+// GetDosesSince returns a copy of the user's doses ingested after 'since'.
+// Useful for rolling-window totals (e.g., "caffeine in the last 24h").
+func (s *SessionStore) GetDosesSince(userID string, since time.Time) []domain.ActiveDose {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var recent []domain.ActiveDose
+	for _, dose := range s.stacks[userID] {
+		if dose.IngestedAt.After(since) {
+			recent = append(recent, dose)
+		}
+	}
+	return recent
+}
+
 // GetAllSessions returns a snapshot of all active users.
 // This is the method that was missing!
 func (s *SessionStore) GetAllSessions() map[string][]domain.ActiveDose {